@@ -0,0 +1,133 @@
+package glmki3d
+
+import (
+	"errors"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+/* GL resources shared by the glmki3d data of one GL context */
+
+// contextData is data made with a Context, which is deleted by Close if it has not been deleted before
+type contextData interface {
+	DeleteData()
+}
+
+// Context owns the GL resources shared by the glmki3d data made in one GL context: the compiled shaders,
+// the auxiliary buffers of the texture generator and the cache of the textures.
+// Make a separate Context for each GL context and use it only when its GL context is current.
+// Close deletes the resources of the Context and all data made with it, which has not been deleted yet.
+// All contexts call the GL functions of the same global backend (see SetGLBackend).
+type Context struct {
+	ShaderPtr    *Shader          // shaders used by the DataShaders of the context
+	InstancedPtr *ShaderInstanced // instanced shaders (compiled by the first MakeDataShaderInstanced)
+	CachePtr     *TextureCache    // textures shared by the DataShaders of the context
+
+	// hBuffer is an auxiliary buffer used for texture generation
+	hBufferId   uint32
+	hBufferSize int // number of the values in hBuffer
+
+	// frameBuffer is a frame to which a texture image is attached to be drawed on
+	frameBufferId uint32
+
+	owned map[contextData]bool // data made with the context and not deleted yet
+}
+
+// MakeContext either returns a pointer to a new Context with compiled shaders or an error.
+// The GL context should be current.
+func MakeContext() (ctx *Context, err error) {
+	shaderPtr, err := MakeShader()
+	if err != nil {
+		return nil, err
+	}
+	ctx = &Context{ShaderPtr: shaderPtr, owned: make(map[contextData]bool)}
+	ctx.CachePtr = MakeTextureCache(ctx)
+	return ctx, nil
+}
+
+// Close deletes in GL all data made with ctx (as DeleteData) and the resources of ctx.
+// The GL context should be still current. ctx can not be used after Close.
+func (ctx *Context) Close() {
+	for data := range ctx.owned {
+		data.DeleteData() // removes data from ctx.owned
+	}
+	ctx.owned = nil
+	if ctx.CachePtr != nil {
+		ctx.CachePtr.Delete()
+		ctx.CachePtr = nil
+	}
+	if ctx.ShaderPtr != nil {
+		ctx.ShaderPtr.Delete()
+		ctx.ShaderPtr = nil
+	}
+	if ctx.InstancedPtr != nil {
+		ctx.InstancedPtr.Delete()
+		ctx.InstancedPtr = nil
+	}
+	glb.DeleteBuffers(1, &ctx.hBufferId) // zeros are ignored by GL
+	glb.DeleteFramebuffers(1, &ctx.frameBufferId)
+	ctx.hBufferId, ctx.hBufferSize, ctx.frameBufferId = 0, 0, 0
+}
+
+// check returns an error if ctx is nil or closed
+func (ctx *Context) check() error {
+	if ctx == nil {
+		return errors.New("ctx == nil // type *Context")
+	}
+	if ctx.ShaderPtr == nil {
+		return errors.New("ctx.ShaderPtr == nil // type *Shader (closed context)")
+	}
+	return nil
+}
+
+// own registers data to be deleted by Close
+func (ctx *Context) own(data contextData) {
+	if ctx != nil && ctx.owned != nil {
+		ctx.owned[data] = true
+	}
+}
+
+// disown removes data deleted before Close from the registry of ctx
+func (ctx *Context) disown(data contextData) {
+	if ctx != nil {
+		delete(ctx.owned, data)
+	}
+}
+
+// hBuffer returns the auxiliary buffer with at least size values -2, -1, 0, 1, ...
+func (ctx *Context) hBuffer(size int) uint32 {
+	if ctx.hBufferId == 0 {
+		glb.GenBuffers(1, &ctx.hBufferId)
+		ctx.hBufferSize = 0
+	}
+	if ctx.hBufferSize < size {
+		glb.BindBuffer(gl.ARRAY_BUFFER, ctx.hBufferId)
+
+		hIn := make([]float32, size)
+		for i := range hIn {
+			hIn[i] = float32(i - 2)
+		}
+		glb.BufferData(gl.ARRAY_BUFFER, len(hIn)*4 /* 4 bytes per float32 */, gl.Ptr(&hIn[0]), gl.STATIC_DRAW)
+		ctx.hBufferSize = len(hIn)
+	}
+	return ctx.hBufferId
+}
+
+// frameBuffer returns the framebuffer object to which the textures are attached to be drawn on
+func (ctx *Context) frameBuffer() uint32 {
+	if ctx.frameBufferId == 0 {
+		glb.GenFramebuffers(1, &ctx.frameBufferId)
+	}
+	return ctx.frameBufferId
+}
+
+// instancedShader either returns the instanced shaders of ctx (compiled if needed) or an error
+func (ctx *Context) instancedShader() (*ShaderInstanced, error) {
+	if ctx.InstancedPtr == nil {
+		shaderPtr, err := MakeShaderInstanced()
+		if err != nil {
+			return nil, err
+		}
+		ctx.InstancedPtr = shaderPtr
+	}
+	return ctx.InstancedPtr, nil
+}
@@ -0,0 +1,149 @@
+package glmki3d
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/mki1967/go-mki3d/mki3d"
+	"testing"
+)
+
+func TestContextClose(t *testing.T) {
+	fake := useFakeGL(t)
+	ctx, ds := makeTestDataShader(t)
+	ds2, err := MakeDataShader(ctx, ds.Mki3dPtr) // shares the textures with ds
+	if err != nil {
+		t.Fatal(err)
+	}
+	di, err := MakeDataShaderInstanced(ctx, ds, []mgl32.Mat4{mgl32.Ident4(), mgl32.Translate3D(1, 0, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc, err := MakeDataShaderCursor(ctx, ds.UniPtr, ds.Mki3dPtr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range []contextData{ds, ds2, di, dc} {
+		if !ctx.owned[data] {
+			t.Errorf("%T not owned by the context", data)
+		}
+	}
+	if len(fake.Programs) != 6 {
+		t.Errorf("%d programs, want 6 (with the instanced ones)", len(fake.Programs))
+	}
+	hBufferId, frameBufferId := ctx.hBufferId, ctx.frameBufferId
+	if hBufferId == 0 || frameBufferId == 0 {
+		t.Fatalf("hBuffer %d, framebuffer %d not made by the texture generation", hBufferId, frameBufferId)
+	}
+
+	ctx.Close()
+
+	if _, ok := fake.Buffers[hBufferId]; ok {
+		t.Error("hBuffer not deleted")
+	}
+	if fake.Framebuffers[frameBufferId] {
+		t.Error("framebuffer not deleted")
+	}
+	if len(fake.Programs) != 0 {
+		t.Errorf("%d programs not deleted", len(fake.Programs))
+	}
+	if len(fake.Textures) != 0 {
+		t.Errorf("%d textures not deleted", len(fake.Textures))
+	}
+	if fake.LiveObjects() != 0 {
+		t.Errorf("%d objects not deleted: buffers %v, vertex arrays %v", fake.LiveObjects(), fake.Buffers, fake.VertexArrays)
+	}
+	if ctx.owned != nil || ctx.ShaderPtr != nil || ctx.InstancedPtr != nil || ctx.CachePtr != nil {
+		t.Errorf("closed context %+v", ctx)
+	}
+}
+
+func TestContextDeleteDataBeforeClose(t *testing.T) {
+	fake := useFakeGL(t)
+	ctx, ds := makeTestDataShader(t)
+	di, err := MakeDataShaderInstanced(ctx, ds, []mgl32.Mat4{mgl32.Ident4()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc, err := MakeDataShaderCursor(ctx, ds.UniPtr, ds.Mki3dPtr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	di.DeleteData()
+	ds.DeleteData()
+	dc.DeleteData()
+	if len(ctx.owned) != 0 {
+		t.Errorf("%d deleted objects still owned by the context", len(ctx.owned))
+	}
+
+	deletedArrays, deletedBuffers := fake.CallCount("DeleteVertexArrays"), fake.CallCount("DeleteBuffers")
+	ctx.Close()
+	if n := fake.CallCount("DeleteVertexArrays") - deletedArrays; n != 0 {
+		t.Errorf("Close deleted vertex arrays %d times again", n)
+	}
+	if n := fake.CallCount("DeleteBuffers") - deletedBuffers; n != 1 {
+		t.Errorf("Close deleted buffers %d times, want once (hBuffer)", n)
+	}
+	if fake.LiveObjects() != 0 {
+		t.Errorf("%d objects not deleted", fake.LiveObjects())
+	}
+}
+
+func TestContextCheck(t *testing.T) {
+	fake := useFakeGL(t)
+	live, ds := makeTestDataShader(t)
+	defer live.Close()
+	closed, err := MakeContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+
+	m := ds.Mki3dPtr
+	constructors := map[string]func(ctx *Context) error{
+		"MakeDataShader": func(ctx *Context) error {
+			_, err := MakeDataShader(ctx, m)
+			return err
+		},
+		"MakeDataShaderTex": func(ctx *Context) error {
+			_, err := MakeDataShaderTex(ctx, MakeGLUni(), m)
+			return err
+		},
+		"MakeGLDataTexEl": func(ctx *Context) error {
+			_, err := MakeGLDataTexEl(ctx, &m.Texture.Elements[0], live.ShaderPtr.TexPtr)
+			return err
+		},
+		"MakeDataShaderCursor": func(ctx *Context) error {
+			_, err := MakeDataShaderCursor(ctx, MakeGLUni(), m)
+			return err
+		},
+		"MakeDataShaderInstanced": func(ctx *Context) error {
+			_, err := MakeDataShaderInstanced(ctx, ds, nil)
+			return err
+		},
+		"MakeDataShaderLOD": func(ctx *Context) error {
+			_, err := MakeDataShaderLOD(ctx, []*mki3d.Mki3dType{m}, []float32{0})
+			return err
+		},
+		"MakeDataShaderTexAtlas": func(ctx *Context) error {
+			_, err := MakeDataShaderTexAtlas(ctx, MakeGLUni(), m, LayoutSeparate, 0, false)
+			return err
+		},
+		"GenerateTexture": func(ctx *Context) error {
+			_, err := GenerateTexture(ctx, m.Texture.Elements[0].Def)
+			return err
+		},
+	}
+
+	liveObjects := fake.LiveObjects()
+	for name, constructor := range constructors {
+		if constructor(nil) == nil {
+			t.Errorf("%s accepts nil context", name)
+		}
+		if constructor(closed) == nil {
+			t.Errorf("%s accepts closed context", name)
+		}
+	}
+	if fake.LiveObjects() != liveObjects {
+		t.Errorf("%d objects made with nil or closed context", fake.LiveObjects()-liveObjects)
+	}
+}
@@ -0,0 +1,71 @@
+package glmki3d
+
+import (
+	"errors"
+	"github.com/mki1967/go-mki3d/mki3d"
+)
+
+// DataShaderCursor is a binding between the segments of the cursor and markers of mki3d data and a shader for segments.
+// It has its own buffers and can be shown or hidden.
+type DataShaderCursor struct {
+	SegPtr  *DataShaderSeg // binding of the cursor segments with the segment shader
+	Visible bool           // the cursor is drawn only if Visible is true
+	CtxPtr  *Context       // context of the cursor
+}
+
+// MakeDataShaderCursor either returns a pointer to a newly created DataShaderCursor or an error.
+// The parameters should be pointers to existing and initiated objects.
+// The segments are made from mPtr.Cursor and the returned DataShaderCursor is visible.
+// It uses the segment shader of ctx and it is deleted by ctx.Close (if not deleted before).
+func MakeDataShaderCursor(ctx *Context, uPtr *GLUni, mPtr *mki3d.Mki3dType) (dcPtr *DataShaderCursor, err error) {
+	if err := ctx.check(); err != nil {
+		return nil, err
+	}
+	if mPtr == nil {
+		return nil, errors.New("mPtr == nil // type *Mki3dType ")
+	}
+
+	bPtr, err := MakeGLBufSegFromSegments(mPtr.Cursor.GetSegments())
+	if err != nil {
+		return nil, err
+	}
+
+	segPtr, err := MakeDataShaderSeg(ctx.ShaderPtr.SegPtr, bPtr, uPtr, mPtr)
+	if err != nil {
+		bPtr.Delete()
+		return nil, err
+	}
+
+	dc := DataShaderCursor{SegPtr: segPtr, Visible: true, CtxPtr: ctx}
+	ctx.own(&dc)
+	return &dc, nil
+}
+
+// Update reloads the buffers of dc from the current state of dc.SegPtr.Mki3dPtr.Cursor.
+// Call it after the cursor or the markers have been changed.
+func (dc *DataShaderCursor) Update() {
+	dc.SegPtr.BufPtr.LoadSegments(dc.SegPtr.Mki3dPtr.Cursor.GetSegments())
+}
+
+// Deletes GL data bound to the dc when no longer needed
+func (dc *DataShaderCursor) DeleteData() {
+	dc.SegPtr.BufPtr.Delete()
+	glb.DeleteVertexArrays(1, &dc.SegPtr.VAO)
+	dc.CtxPtr.disown(dc)
+}
+
+// Draw the cursor (if visible) with the current model uniform.
+func (dc *DataShaderCursor) DrawModel() {
+	if !dc.Visible {
+		return
+	}
+	dc.SegPtr.DrawModel()
+}
+
+// Draw the cursor (if visible) as a stage.
+func (dc *DataShaderCursor) DrawStage() {
+	if !dc.Visible {
+		return
+	}
+	dc.SegPtr.DrawStage()
+}
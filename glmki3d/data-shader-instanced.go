@@ -0,0 +1,217 @@
+package glmki3d
+
+import (
+	"errors"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// GLBufInstances contains reference to GL buffer with model matrices of instances
+type GLBufInstances struct {
+	Count int32  // number of instances - the last argument for gl.DrawArraysInstanced
+	Buf   uint32 // model matrices (16 float32 values per instance)
+}
+
+// Delete the buffer in GL, when it is not needed any more
+func (glBuf *GLBufInstances) Delete() {
+	glb.DeleteBuffers(1, &glBuf.Buf)
+}
+
+// LoadMatrices loads model matrices of instances to the GL buffer referenced by glBuf
+func (glBuf *GLBufInstances) LoadMatrices(matrices []mgl32.Mat4) {
+	glBuf.Count = int32(len(matrices))
+	if glBuf.Count == 0 {
+		return // do not create empty buffers
+	}
+	glb.BindBuffer(gl.ARRAY_BUFFER, glBuf.Buf)
+	glb.BufferData(gl.ARRAY_BUFFER, len(matrices)*16*4 /* 4 bytes per float32 */, gl.Ptr(&matrices[0][0]), gl.DYNAMIC_DRAW)
+	glb.BindBuffer(gl.ARRAY_BUFFER, 0) // unbind
+}
+
+// MakeGLBufInstances either returns pointer to a new GLBufInstances loaded with matrices or an error
+func MakeGLBufInstances(matrices []mgl32.Mat4) (glBufPtr *GLBufInstances, err error) {
+	var glBuf GLBufInstances
+	glb.GenBuffers(1, &glBuf.Buf)
+	if glBuf.Buf == 0 {
+		return nil, errors.New("glBuf.Buf == 0 // GenBuffers failed")
+	}
+	glBuf.LoadMatrices(matrices)
+	return &glBuf, nil
+}
+
+// InstanceMatrix returns the model matrix of an instance
+// scaled by scale, rotated by rotation and moved to position.
+func InstanceMatrix(position mgl32.Vec3, rotation mgl32.Mat3, scale float32) mgl32.Mat4 {
+	m := rotation.Mul(scale).Mat4()
+	m.SetCol(3, position.Vec4(1))
+	return m
+}
+
+// bindInstances adds the instance model matrix attribute from glBuf to the currently bound VAO
+func (glBuf *GLBufInstances) bindInstances() {
+	glb.BindBuffer(gl.ARRAY_BUFFER, glBuf.Buf)
+	for i := uint32(0); i < 4; i++ { // one attribute for each column
+		glb.EnableVertexAttribArray(instanceModelAttr + i)
+		glb.VertexAttribPointer(instanceModelAttr+i, 4, gl.FLOAT, false, 16*4 /* stride */, gl.PtrOffset(int(i)*4*4))
+		glb.VertexAttribDivisor(instanceModelAttr+i, 1)
+	}
+	glb.BindBuffer(gl.ARRAY_BUFFER, 0) // unbind
+}
+
+// DataShaderInstanced is a binding between the data of a DataShader, the instanced shaders and the instances buffer.
+// The GL buffers and textures are shared with the DataShader and only the VAOs are owned by DataShaderInstanced.
+type DataShaderInstanced struct {
+	SegPtr     *DataShaderSeg  // segments bound to the instanced shader
+	TrPtr      *DataShaderTr   // triangles bound to the instanced shader
+	TexPtr     *DataShaderTex  // textured triangles bound to the instanced shader (nil if there are no textures)
+	InstBufPtr *GLBufInstances // model matrices of the instances
+	CtxPtr     *Context        // context of the instanced shaders
+}
+
+// MakeDataShaderInstanced either returns a pointer to a newly created DataShaderInstanced
+// for drawing the data of dsPtr with the model matrices of the instances or an error.
+// The changes of the texture elements of dsPtr made later (see DataShader.UpdateTextures) are not
+// visible in the returned DataShaderInstanced - make a new one after such changes.
+// The instanced shaders of ctx are compiled by the first call for ctx.
+// The returned DataShaderInstanced is deleted by ctx.Close (if not deleted before).
+func MakeDataShaderInstanced(ctx *Context, dsPtr *DataShader, matrices []mgl32.Mat4) (diPtr *DataShaderInstanced, err error) {
+	if err := ctx.check(); err != nil {
+		return nil, err
+	}
+	if dsPtr == nil {
+		return nil, errors.New("dsPtr == nil // type *DataShader ")
+	}
+	sPtr, err := ctx.instancedShader()
+	if err != nil {
+		return nil, err
+	}
+
+	instBufPtr, err := MakeGLBufInstances(matrices)
+	if err != nil {
+		return nil, err
+	}
+
+	segPtr, err := MakeDataShaderSeg(sPtr.SegPtr, dsPtr.SegPtr.BufPtr, dsPtr.UniPtr, dsPtr.Mki3dPtr)
+	if err != nil {
+		instBufPtr.Delete()
+		return nil, err
+	}
+	glb.BindVertexArray(segPtr.VAO)
+	instBufPtr.bindInstances()
+	glb.BindVertexArray(0)
+
+	trPtr, err := MakeDataShaderTr(sPtr.TrPtr, dsPtr.TrPtr.BufPtr, dsPtr.UniPtr, dsPtr.Mki3dPtr)
+	if err != nil {
+		instBufPtr.Delete()
+		glb.DeleteVertexArrays(1, &segPtr.VAO)
+		return nil, err
+	}
+	glb.BindVertexArray(trPtr.VAO)
+	instBufPtr.bindInstances()
+	glb.BindVertexArray(0)
+
+	var texPtr *DataShaderTex
+	if dsPtr.TexPtr != nil {
+		dataElements := make([]*GLDataTexEl, 0, len(dsPtr.TexPtr.DataElements))
+		for _, texEl := range dsPtr.TexPtr.DataElements {
+			el := *texEl // shares the buffers and the texture
+			el.InitVAO(sPtr.TexPtr)
+			glb.BindVertexArray(el.VAO)
+			instBufPtr.bindInstances()
+			glb.BindVertexArray(0)
+			dataElements = append(dataElements, &el)
+		}
+		texPtr = &DataShaderTex{ShaderPtr: sPtr.TexPtr, DataElements: dataElements,
+			UniPtr: dsPtr.UniPtr, Mki3dPtr: dsPtr.Mki3dPtr, Layout: dsPtr.Layout, CtxPtr: ctx}
+	}
+
+	di := DataShaderInstanced{SegPtr: segPtr, TrPtr: trPtr, TexPtr: texPtr, InstBufPtr: instBufPtr, CtxPtr: ctx}
+	ctx.own(&di)
+	return &di, nil
+}
+
+// LoadMatrices replaces the model matrices of the instances of di
+func (di *DataShaderInstanced) LoadMatrices(matrices []mgl32.Mat4) {
+	di.InstBufPtr.LoadMatrices(matrices)
+}
+
+// Deletes GL data owned by di (VAOs and the instances buffer) when no longer needed.
+// The shared buffers and textures are deleted by DeleteData of the DataShader.
+func (di *DataShaderInstanced) DeleteData() {
+	glb.DeleteVertexArrays(1, &di.SegPtr.VAO)
+	glb.DeleteVertexArrays(1, &di.TrPtr.VAO)
+	if di.TexPtr != nil {
+		for _, texEl := range di.TexPtr.DataElements {
+			glb.DeleteVertexArrays(1, &texEl.VAO)
+		}
+	}
+	di.InstBufPtr.Delete()
+	di.CtxPtr.disown(di)
+}
+
+// InitStage initiates stage parameters of the instanced shaders.
+func (di *DataShaderInstanced) InitStage() (err error) {
+	err = di.SegPtr.InitStage()
+	if err != nil {
+		return err
+	}
+
+	err = di.TrPtr.InitStage()
+	if err != nil {
+		return err
+	}
+
+	if di.TexPtr != nil {
+		err = di.TexPtr.InitStage()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Draw all instances of the model (segments, triangles and textured triangles).
+func (di *DataShaderInstanced) DrawModel() {
+	instances := di.InstBufPtr.Count
+	if instances == 0 {
+		return // nothing to draw
+	}
+
+	if di.TrPtr.BufPtr.VertexCount != 0 {
+		di.TrPtr.UniModelToShader()
+		glb.UseProgram(di.TrPtr.ShaderPtr.ProgramId)
+		glb.BindVertexArray(di.TrPtr.VAO)
+		glb.DrawArraysInstanced(gl.TRIANGLES, 0, di.TrPtr.BufPtr.VertexCount, instances)
+		glb.BindVertexArray(0)
+	}
+
+	if di.SegPtr.BufPtr.VertexCount != 0 {
+		di.SegPtr.UniModelToShader()
+		glb.UseProgram(di.SegPtr.ShaderPtr.ProgramId)
+		glb.BindVertexArray(di.SegPtr.VAO)
+		glb.DrawArraysInstanced(gl.LINES, 0, di.SegPtr.BufPtr.VertexCount, instances)
+		glb.BindVertexArray(0)
+	}
+
+	if di.TexPtr != nil {
+		di.TexPtr.UniModelToShader()
+		di.TexPtr.UniTexSamplerUniToShader(0) // use zero as default texture unit
+		glb.UseProgram(di.TexPtr.ShaderPtr.ProgramId)
+		for _, texEl := range di.TexPtr.DataElements {
+			if texEl.VertexCount != 0 {
+				glb.BindVertexArray(texEl.VAO)
+				glb.ActiveTexture(gl.TEXTURE0 + 0) // use zero as default texture unit
+				glb.BindTexture(gl.TEXTURE_2D, texEl.Texture)
+				glb.DrawArraysInstanced(gl.TRIANGLES, 0, texEl.VertexCount, instances)
+				glb.BindVertexArray(0)
+			}
+		}
+	}
+}
+
+// Draw all instances as a stage.
+func (di *DataShaderInstanced) DrawStage() {
+	di.InitStage()
+	di.DrawModel()
+}
@@ -0,0 +1,34 @@
+package glmki3d
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"testing"
+)
+
+func TestMakeDataShaderInstancedErrors(t *testing.T) {
+	fake := useFakeGL(t)
+	ctx, ds := makeTestDataShader(t)
+	defer ctx.Close()
+	if _, err := ctx.instancedShader(); err != nil { // compile the shared shaders before counting the objects
+		t.Fatal(err)
+	}
+	matrices := []mgl32.Mat4{mgl32.Ident4(), mgl32.Translate3D(1, 0, 0)}
+
+	noSeg := *ds
+	noSeg.SegPtr = &DataShaderSeg{} // MakeDataShaderSeg fails
+	noTr := *ds
+	noTr.TrPtr = &DataShaderTr{} // MakeDataShaderTr fails after the segments have been made
+
+	for name, bad := range map[string]*DataShader{"segments": &noSeg, "triangles": &noTr} {
+		liveObjects := fake.LiveObjects()
+		if _, err := MakeDataShaderInstanced(ctx, bad, matrices); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+		if fake.LiveObjects() != liveObjects {
+			t.Errorf("%s: %d objects left in GL", name, fake.LiveObjects()-liveObjects)
+		}
+	}
+	if len(ctx.owned) != 1 {
+		t.Errorf("%d objects owned by the context, want 1", len(ctx.owned))
+	}
+}
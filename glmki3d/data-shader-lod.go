@@ -0,0 +1,97 @@
+package glmki3d
+
+import (
+	"errors"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/mki1967/go-mki3d/mki3d"
+)
+
+// DataShaderLOD contains DataShaders of the levels of detail of a model (see mki3d.MakeLODChain)
+// sharing the same uniforms. The level is selected by the size of the model on the screen.
+type DataShaderLOD struct {
+	Levels   []*DataShader // from the most detailed one
+	MinSizes []float32     // Levels[i] is used if the model is at least MinSizes[i] pixels high on the screen
+	UniPtr   *GLUni        // uniforms shared by all levels
+	Center   mgl32.Vec3    // center of the bounding sphere of the model (in model coordinates)
+	Radius   float32       // radius of the bounding sphere of the model
+}
+
+// MakeDataShaderLOD either returns a pointer to a new DataShaderLOD for the levels of detail lods
+// made in ctx or an error. minSizes should be decreasing and have the same length as lods.
+// The levels share the texture cache of ctx, since they usually have the same textures.
+func MakeDataShaderLOD(ctx *Context, lods []*mki3d.Mki3dType, minSizes []float32) (dlPtr *DataShaderLOD, err error) {
+	if len(lods) == 0 {
+		return nil, errors.New("len(lods) == 0 // type []*mki3d.Mki3dType")
+	}
+	if len(minSizes) != len(lods) {
+		return nil, errors.New("len(minSizes) != len(lods)")
+	}
+
+	uPtr := MakeGLUni()
+	levels := make([]*DataShader, 0, len(lods))
+	for _, mPtr := range lods {
+		dsPtr, err := MakeDataShader(ctx, mPtr)
+		if err != nil {
+			for _, level := range levels {
+				level.DeleteData()
+			}
+			return nil, err
+		}
+		dsPtr.SetUniPtr(uPtr)
+		levels = append(levels, dsPtr)
+	}
+
+	// bounding sphere from the bounding box of the most detailed level
+	box := lods[0].BoundingBox()
+	var center mgl32.Vec3
+	var radius float32
+	if !box.Empty {
+		min, max := mgl32.Vec3(box.Min), mgl32.Vec3(box.Max)
+		center = min.Add(max).Mul(0.5)
+		radius = max.Sub(min).Len() / 2
+	}
+
+	return &DataShaderLOD{Levels: levels, MinSizes: minSizes, UniPtr: uPtr, Center: center, Radius: radius}, nil
+}
+
+// ScreenSize returns the approximate height (in pixels) of the bounding sphere of the model
+// drawn with the current uniforms of dl in the window of the given height.
+// It returns 0 if the center of the model is behind the viewer.
+func (dl *DataShaderLOD) ScreenSize(height int) float32 {
+	modelView := dl.UniPtr.ViewUni.Mul4(dl.UniPtr.ModelUni)
+	w := dl.UniPtr.ProjectionUni.Mul4(modelView).Mul4x1(dl.Center.Vec4(1))[3] // perspective divisor
+	if w <= 0 {
+		return 0
+	}
+	scale := modelView.Col(0).Vec3().Len()    // scaling of the radius by model and view
+	zoomY := dl.UniPtr.ProjectionUni.At(1, 1) // see ProjectionMatrix
+	return dl.Radius * scale * zoomY / w * float32(height)
+}
+
+// Select returns the level of detail for the model drawn in the window of the given height.
+func (dl *DataShaderLOD) Select(height int) *DataShader {
+	size := dl.ScreenSize(height)
+	for i, minSize := range dl.MinSizes {
+		if size >= minSize {
+			return dl.Levels[i]
+		}
+	}
+	return dl.Levels[len(dl.Levels)-1]
+}
+
+// Deletes GL data of all levels when no longer needed
+func (dl *DataShaderLOD) DeleteData() {
+	for _, level := range dl.Levels {
+		level.DeleteData()
+	}
+}
+
+// Draw the model with the level of detail selected for the window of the given height.
+func (dl *DataShaderLOD) DrawModel(height int) {
+	dl.Select(height).DrawModel()
+}
+
+// Draw a stage with the level of detail selected for the window of the given height.
+func (dl *DataShaderLOD) DrawStage(height int) {
+	dl.Select(height).DrawStage()
+}
@@ -11,42 +11,74 @@ import (
 type DataShaderTex struct {
 	ShaderPtr *ShaderTex // pointer to the GL shader program structure
 	// VAO       uint32           // GL Vertex Array Object // each GLDataTexEl has its own VAO
-	DataElements []*GLDataTexEl   // silce of GL data structures for texture elements
-	UniPtr       *GLUni           // pointer to GL uniform parameters structure
-	Mki3dPtr     *mki3d.Mki3dType // pointer to original Mki3dType data
+	DataElements []*GLDataTexEl     // silce of GL data structures for texture elements
+	UniPtr       *GLUni             // pointer to GL uniform parameters structure
+	Mki3dPtr     *mki3d.Mki3dType   // pointer to original Mki3dType data
+	Layout       BufferLayout       // layout of the buffers of DataElements
+	AtlasPtr     *mki3d.AtlasType   // if not nil, DataElements contain single element with all textures packed into the atlas
+	TexOptions   TextureOptionsFunc // options of the textures of the elements (nil means DefaultTextureOptions)
+	CachePtr     *TextureCache      // cache of the textures of the elements
+	CtxPtr       *Context           // context of the DataShaderTex (needed for updates)
 }
 
 // MakeDataShaderTex either returns a pointer to a newly created DataShaderTex or an error.
 // The parameters should be pointers to existing and initiated objects.
 // If mPtr.Texture!=nil then the function creates DataElements of the returned DataShaderTex
 // If mPtr.Texture == nil then the function returns (nil, nil)
-func MakeDataShaderTex(sPtr *ShaderTex, uPtr *GLUni, mPtr *mki3d.Mki3dType) (dsPtr *DataShaderTex, err error) {
+func MakeDataShaderTex(ctx *Context, uPtr *GLUni, mPtr *mki3d.Mki3dType) (dsPtr *DataShaderTex, err error) {
+	return MakeDataShaderTexWithLayout(ctx, uPtr, mPtr, LayoutSeparate)
+}
+
+// MakeDataShaderTexWithLayout works as MakeDataShaderTex
+// with the vertex attributes of the texture elements stored in GL buffers with the given layout.
+func MakeDataShaderTexWithLayout(ctx *Context, uPtr *GLUni, mPtr *mki3d.Mki3dType, layout BufferLayout) (dsPtr *DataShaderTex, err error) {
+	return MakeDataShaderTexWithOptions(ctx, uPtr, mPtr, layout, nil)
+}
+
+// MakeDataShaderTexWithOptions works as MakeDataShaderTexWithLayout
+// with the textures of the elements generated with the options given by texOptions (nil means DefaultTextureOptions).
+func MakeDataShaderTexWithOptions(ctx *Context, uPtr *GLUni, mPtr *mki3d.Mki3dType, layout BufferLayout, texOptions TextureOptionsFunc) (dsPtr *DataShaderTex, err error) {
+	return MakeDataShaderTexWithCache(ctx, uPtr, mPtr, layout, texOptions, nil)
+}
+
+// MakeDataShaderTexWithCache works as MakeDataShaderTexWithOptions with the textures acquired from cache,
+// which can be shared with other DataShaderTex of ctx. If cache is nil, then the cache of ctx is used,
+// so that the elements with equal texture definitions share a single texture.
+func MakeDataShaderTexWithCache(ctx *Context, uPtr *GLUni, mPtr *mki3d.Mki3dType, layout BufferLayout, texOptions TextureOptionsFunc, cache *TextureCache) (dsPtr *DataShaderTex, err error) {
 	if mPtr == nil {
 		return nil, errors.New("mPtr == nil // type *Mki3dType ")
 	}
 	if mPtr.Texture == nil { // there are no textures
 		return nil, nil
 	}
-	if sPtr == nil {
-		return nil, errors.New("sPtr == nil // type *ShaderTr ")
+	if err := ctx.check(); err != nil {
+		return nil, err
 	}
+	sPtr := ctx.ShaderPtr.TexPtr
 	if uPtr == nil {
 		return nil, errors.New("uPtr == nil // type *GLUni ")
 	}
 
+	if cache == nil {
+		cache = ctx.CachePtr
+	}
+
 	dataElements := make([]*GLDataTexEl, 0, len(mPtr.Texture.Elements))
-	//// TO DO
 
-	for _, texEl := range mPtr.Texture.Elements {
-		dataElement, err := MakeGLDataTexEl(&texEl, sPtr)
+	for i := range mPtr.Texture.Elements {
+		texEl := &mPtr.Texture.Elements[i]
+		dataElement, err := MakeGLDataTexElWithCache(ctx, texEl, sPtr, layout, texOptions.textureOptions(i, texEl), cache)
 		if err != nil {
+			for _, made := range dataElements {
+				made.Delete() // releases the textures to the cache
+			}
 			return nil, err
 		}
 		dataElements = append(dataElements, dataElement)
 
 	}
 
-	ds := DataShaderTex{ShaderPtr: sPtr, DataElements: dataElements, UniPtr: uPtr, Mki3dPtr: mPtr}
+	ds := DataShaderTex{ShaderPtr: sPtr, DataElements: dataElements, UniPtr: uPtr, Mki3dPtr: mPtr, Layout: layout, TexOptions: texOptions, CachePtr: cache, CtxPtr: ctx}
 
 	return &ds, nil
 }
@@ -56,8 +88,8 @@ func (ds *DataShaderTex) UniTexSamplerUniToShader(texUnit int32) (err error) {
 	if ds.ShaderPtr == nil {
 		return errors.New("ds.ShaderPtr == nil // type *ShaderTr")
 	}
-	gl.UseProgram(ds.ShaderPtr.ProgramId)
-	gl.Uniform1i(ds.ShaderPtr.TexSamplerUni, texUnit)
+	glb.UseProgram(ds.ShaderPtr.ProgramId)
+	glb.Uniform1i(ds.ShaderPtr.TexSamplerUni, texUnit)
 	return nil
 }
 
@@ -70,9 +102,25 @@ func (ds *DataShaderTex) UniLightToShader() (err error) {
 		return errors.New("ds.UniPtr == nil // type *GLUni")
 	}
 
-	gl.UseProgram(ds.ShaderPtr.ProgramId)
-	gl.Uniform3fv(ds.ShaderPtr.LightUni, 1, &(ds.UniPtr.LightUni[0]))
-	gl.Uniform1f(ds.ShaderPtr.AmbientUni, ds.UniPtr.AmbientUni)
+	glb.UseProgram(ds.ShaderPtr.ProgramId)
+	glb.Uniform3fv(ds.ShaderPtr.LightUni, 1, &(ds.UniPtr.LightUni[0]))
+	glb.Uniform1f(ds.ShaderPtr.AmbientUni, ds.UniPtr.AmbientUni)
+
+	return nil
+}
+
+// UniClipToShader sets the clipping box uniform parameters from ds.UniPtr to ds.ShaderPtr.
+func (ds *DataShaderTex) UniClipToShader() (err error) {
+	if ds.ShaderPtr == nil {
+		return errors.New("ds.ShaderPtr == nil // type *ShaderTex")
+	}
+	if ds.UniPtr == nil {
+		return errors.New("ds.UniPtr == nil // type *GLUni")
+	}
+
+	glb.UseProgram(ds.ShaderPtr.ProgramId)
+	glb.Uniform3fv(ds.ShaderPtr.ClipMinUni, 1, &(ds.UniPtr.ClipMinUni[0]))
+	glb.Uniform3fv(ds.ShaderPtr.ClipMaxUni, 1, &(ds.UniPtr.ClipMaxUni[0]))
 
 	return nil
 }
@@ -86,8 +134,8 @@ func (ds *DataShaderTex) UniModelToShader() (err error) {
 		return errors.New("ds.UniPtr == nil // type *GLUni")
 	}
 
-	gl.UseProgram(ds.ShaderPtr.ProgramId)
-	gl.UniformMatrix4fv(ds.ShaderPtr.ModelUni, 1, false, &(ds.UniPtr.ModelUni[0]))
+	glb.UseProgram(ds.ShaderPtr.ProgramId)
+	glb.UniformMatrix4fv(ds.ShaderPtr.ModelUni, 1, false, &(ds.UniPtr.ModelUni[0]))
 
 	return nil
 }
@@ -101,8 +149,8 @@ func (ds *DataShaderTex) UniViewToShader() (err error) {
 		return errors.New("ds.UniPtr == nil // type *GLUni")
 	}
 
-	gl.UseProgram(ds.ShaderPtr.ProgramId)
-	gl.UniformMatrix4fv(ds.ShaderPtr.ViewUni, 1, false, &(ds.UniPtr.ViewUni[0]))
+	glb.UseProgram(ds.ShaderPtr.ProgramId)
+	glb.UniformMatrix4fv(ds.ShaderPtr.ViewUni, 1, false, &(ds.UniPtr.ViewUni[0]))
 
 	return nil
 }
@@ -116,8 +164,8 @@ func (ds *DataShaderTex) UniProjectionToShader() (err error) {
 		return errors.New("ds.UniPtr == nil // type *GLUni")
 	}
 
-	gl.UseProgram(ds.ShaderPtr.ProgramId)
-	gl.UniformMatrix4fv(ds.ShaderPtr.ProjectionUni, 1, false, &(ds.UniPtr.ProjectionUni[0]))
+	glb.UseProgram(ds.ShaderPtr.ProgramId)
+	glb.UniformMatrix4fv(ds.ShaderPtr.ProjectionUni, 1, false, &(ds.UniPtr.ProjectionUni[0]))
 
 	return nil
 }
@@ -143,6 +191,11 @@ func (ds *DataShaderTex) InitStage() (err error) {
 		return err
 	}
 
+	err = ds.UniClipToShader() // set clipping box
+	if err != nil {
+		return err
+	}
+
 	return nil
 
 }
@@ -151,15 +204,15 @@ func (ds *DataShaderTex) InitStage() (err error) {
 func (ds *DataShaderTex) DrawModel() {
 	ds.UniModelToShader()
 	ds.UniTexSamplerUniToShader(0) // use zero as default texture unit
-	gl.UseProgram(ds.ShaderPtr.ProgramId)
+	glb.UseProgram(ds.ShaderPtr.ProgramId)
 	for _, texEl := range ds.DataElements {
 		if texEl.VertexCount != 0 {
-			gl.BindVertexArray(texEl.VAO)
-			gl.ActiveTexture(gl.TEXTURE0 + 0) // use zero as default texture unit
-			gl.BindTexture(gl.TEXTURE_2D, texEl.Texture)
+			glb.BindVertexArray(texEl.VAO)
+			glb.ActiveTexture(gl.TEXTURE0 + 0) // use zero as default texture unit
+			glb.BindTexture(gl.TEXTURE_2D, texEl.Texture)
 			// fmt.Printf("texEl = %v\n", texEl );
-			gl.DrawArrays(gl.TRIANGLES, 0, texEl.VertexCount)
-			gl.BindVertexArray(0)
+			glb.DrawArrays(gl.TRIANGLES, 0, texEl.VertexCount)
+			glb.BindVertexArray(0)
 		}
 	}
 }
@@ -0,0 +1,197 @@
+package glmki3d
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/mki1967/go-mki3d/mki3d"
+)
+
+/* in-place updates of GL data of editable models */
+
+// minBufferCapacity is the minimal number of vertices allocated when the buffers are reallocated for updates
+const minBufferCapacity = 96
+
+// bufferCapacity returns the number of vertices to be allocated for vertexCount vertices with headroom for growing
+func bufferCapacity(vertexCount int32) int32 {
+	return 2*vertexCount + minBufferCapacity
+}
+
+// clampRange returns the range [first, end) of the first count elements starting from first
+// clamped to the range [0, length).
+func clampRange(first, count, length int) (int, int) {
+	if first < 0 {
+		first = 0
+	}
+	end := first + count
+	if end > length {
+		end = length
+	}
+	if first > end {
+		first = end
+	}
+	return first, end
+}
+
+// writeBuffer writes data to buf starting from the vertex firstVertex (floatsPerVertex float32 values per vertex).
+// If capacity > 0 then buf is reallocated for capacity vertices before writing.
+func writeBuffer(buf uint32, floatsPerVertex, firstVertex int, data []float32, capacity int32) {
+	glb.BindBuffer(gl.ARRAY_BUFFER, buf)
+	if capacity > 0 {
+		glb.BufferData(gl.ARRAY_BUFFER, int(capacity)*floatsPerVertex*4 /* 4 bytes per float32 */, gl.Ptr(nil), gl.DYNAMIC_DRAW)
+	}
+	if len(data) > 0 {
+		glb.BufferSubData(gl.ARRAY_BUFFER, firstVertex*floatsPerVertex*4, len(data)*4 /* 4 bytes per float32 */, gl.Ptr(data))
+	}
+}
+
+// writeTriangles writes triangles to the buffers of glBuf starting from the triangle number first.
+// If capacity > 0 then the buffers are reallocated for capacity vertices before writing.
+func (glBuf *GLBufTr) writeTriangles(triangles mki3d.TrianglesType, first int, capacity int32) {
+	if glBuf.Layout == LayoutInterleaved {
+		writeBuffer(glBuf.VertexBuf, mki3d.TriangleInterleavedSize, 3*first, triangles.GetInterleavedArray(), capacity)
+	} else {
+		writeBuffer(glBuf.PositionBuf, 3, 3*first, triangles.GetPositionArrays(), capacity)
+		writeBuffer(glBuf.NormalBuf, 3, 3*first, triangles.GetNormalArrays(), capacity)
+		writeBuffer(glBuf.ColorBuf, 3, 3*first, triangles.GetColorArrays(), capacity)
+	}
+	glb.BindBuffer(gl.ARRAY_BUFFER, 0) // unbind
+}
+
+// writeSegments writes segments to the buffers of glBuf starting from the segment number first.
+// If capacity > 0 then the buffers are reallocated for capacity vertices before writing.
+func (glBuf *GLBufSeg) writeSegments(segments mki3d.SegmentsType, first int, capacity int32) {
+	if glBuf.Layout == LayoutInterleaved {
+		writeBuffer(glBuf.VertexBuf, mki3d.SegmentInterleavedSize, 2*first, segments.GetInterleavedArray(), capacity)
+	} else {
+		writeBuffer(glBuf.PositionBuf, 3, 2*first, segments.GetPositionArrays(), capacity)
+		writeBuffer(glBuf.ColorBuf, 3, 2*first, segments.GetColorArrays(), capacity)
+	}
+	glb.BindBuffer(gl.ARRAY_BUFFER, 0) // unbind
+}
+
+// UpdateTriangles updates the GL buffers of glBuf after the triangles number first, ..., first+count-1
+// have been changed or the length of triangles has been changed.
+// Only the changed range is written (with gl.BufferSubData), unless the buffers are too small.
+// In that case they are reallocated with headroom for growing and all triangles are loaded.
+func (glBuf *GLBufTr) UpdateTriangles(triangles mki3d.TrianglesType, first, count int) {
+	vertexCount := int32(3 * len(triangles))
+	if vertexCount > glBuf.Capacity {
+		glBuf.Capacity = bufferCapacity(vertexCount)
+		glBuf.VertexCount = vertexCount
+		glBuf.writeTriangles(triangles, 0, glBuf.Capacity)
+		return
+	}
+	glBuf.VertexCount = vertexCount
+	first, end := clampRange(first, count, len(triangles))
+	glBuf.writeTriangles(triangles[first:end], first, 0)
+}
+
+// UpdateSegments updates the GL buffers of glBuf after the segments number first, ..., first+count-1
+// have been changed or the length of segments has been changed.
+// Only the changed range is written (with gl.BufferSubData), unless the buffers are too small.
+// In that case they are reallocated with headroom for growing and all segments are loaded.
+func (glBuf *GLBufSeg) UpdateSegments(segments mki3d.SegmentsType, first, count int) {
+	vertexCount := int32(2 * len(segments))
+	if vertexCount > glBuf.Capacity {
+		glBuf.Capacity = bufferCapacity(vertexCount)
+		glBuf.VertexCount = vertexCount
+		glBuf.writeSegments(segments, 0, glBuf.Capacity)
+		return
+	}
+	glBuf.VertexCount = vertexCount
+	first, end := clampRange(first, count, len(segments))
+	glBuf.writeSegments(segments[first:end], first, 0)
+}
+
+// UpdateTriangles updates GL buffers of ds after the triangles number first, ..., first+count-1
+// of ds.Mki3dPtr.Model.Triangles have been changed or the triangles have been added or removed.
+func (ds *DataShader) UpdateTriangles(first, count int) {
+	ds.TrPtr.BufPtr.UpdateTriangles(ds.Mki3dPtr.Model.Triangles, first, count)
+}
+
+// UpdateSegments updates GL buffers of ds after the segments number first, ..., first+count-1
+// of ds.Mki3dPtr.Model.Segments have been changed or the segments have been added or removed.
+func (ds *DataShader) UpdateSegments(first, count int) {
+	ds.SegPtr.BufPtr.UpdateSegments(ds.Mki3dPtr.Model.Segments, first, count)
+}
+
+// Update updates glData after the texture element texEl has been changed.
+// The texture is regenerated only if texEl.Def differs from glData.Def.
+// The buffers are reloaded.
+func (glData *GLDataTexEl) Update(texEl *mki3d.TextureElementType) error {
+	return glData.UpdateWithOptions(texEl, glData.Options)
+}
+
+// UpdateWithOptions works as Update, but the texture is regenerated also if the options differ from glData.Options.
+func (glData *GLDataTexEl) UpdateWithOptions(texEl *mki3d.TextureElementType, options TextureOptions) error {
+	options = options.normalized()
+	if texEl.Def != glData.Def || options != glData.Options {
+		texture, err := glData.generateTexture(texEl.Def, options)
+		if err != nil {
+			return err
+		}
+		glData.releaseTexture()
+		glData.Texture = texture
+		glData.Def = texEl.Def
+		glData.Options = options
+	}
+	glData.LoadTriangleBufs(texEl)
+	return nil
+}
+
+// Update updates ds.DataElements after the texture elements of ds.Mki3dPtr.Texture have been changed.
+// The textures are regenerated only for the elements with changed definitions or options (see ds.TexOptions).
+// The data of new elements are created and the data of removed elements are deleted.
+// If ds uses a texture atlas, then the atlas is rebuilt.
+func (ds *DataShaderTex) Update() error {
+	if ds.AtlasPtr != nil {
+		return ds.updateAtlas()
+	}
+
+	var elements mki3d.TextureElementsType
+	if ds.Mki3dPtr.Texture != nil {
+		elements = ds.Mki3dPtr.Texture.Elements
+	}
+
+	for i := range elements {
+		if i < len(ds.DataElements) {
+			err := ds.DataElements[i].UpdateWithOptions(&elements[i], ds.TexOptions.textureOptions(i, &elements[i]))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		dataElement, err := MakeGLDataTexElWithCache(ds.CtxPtr, &elements[i], ds.ShaderPtr, ds.Layout, ds.TexOptions.textureOptions(i, &elements[i]), ds.CachePtr)
+		if err != nil {
+			return err
+		}
+		ds.DataElements = append(ds.DataElements, dataElement)
+	}
+
+	// delete the data of removed elements
+	for i := len(elements); i < len(ds.DataElements); i++ {
+		ds.DataElements[i].Delete()
+	}
+	if len(elements) < len(ds.DataElements) {
+		ds.DataElements = ds.DataElements[:len(elements)]
+	}
+
+	return nil
+}
+
+// UpdateTextures updates GL data of ds after the texture elements of ds.Mki3dPtr.Texture have been changed.
+// The textures are regenerated only for the elements with changed definitions or options (see ds.TexOptions).
+func (ds *DataShader) UpdateTextures() error {
+	if ds.TexPtr == nil {
+		if ds.Mki3dPtr.Texture == nil {
+			return nil // still no textures
+		}
+		texPtr, err := MakeDataShaderTexWithCache(ds.CtxPtr, ds.UniPtr, ds.Mki3dPtr, ds.Layout, ds.TexOptions, ds.CachePtr)
+		if err != nil {
+			return err
+		}
+		ds.TexPtr = texPtr
+		return nil
+	}
+	ds.TexPtr.TexOptions = ds.TexOptions
+	return ds.TexPtr.Update()
+}
@@ -29,54 +29,97 @@ type DataShaderSeg struct {
 // DataShader contains SegPtr (a pointer to binding between data and a shader for segments) and
 // TrPtr (a pointer to  binding between data and a shader for triangles)
 type DataShader struct {
-	Mki3dPtr *mki3d.Mki3dType // redundant link to mki3d data
-	UniPtr   *GLUni           // redundant link to uniforms
-	SegPtr   *DataShaderSeg
-	TrPtr    *DataShaderTr
-	TexPtr   *DataShaderTex
+	Mki3dPtr   *mki3d.Mki3dType   // redundant link to mki3d data
+	UniPtr     *GLUni             // redundant link to uniforms
+	ShaderPtr  *Shader            // shaders used by the DataShader (needed for updates)
+	Layout     BufferLayout       // layout of the GL buffers (needed for updates)
+	TexOptions TextureOptionsFunc // options of the textures (nil means DefaultTextureOptions, needed for updates)
+	CachePtr   *TextureCache      // cache of the textures (needed for updates)
+	CtxPtr     *Context           // context of the DataShader (needed for updates)
+	SegPtr     *DataShaderSeg
+	TrPtr      *DataShaderTr
+	TexPtr     *DataShaderTex
 }
 
 // Deletes GL data bound to the dsPtr when no longer needed
 func (dsPtr *DataShader) DeleteData() {
 	dsPtr.SegPtr.BufPtr.Delete()
-	gl.DeleteVertexArrays(1, &dsPtr.SegPtr.VAO)
+	glb.DeleteVertexArrays(1, &dsPtr.SegPtr.VAO)
 	dsPtr.TrPtr.BufPtr.Delete()
-	gl.DeleteVertexArrays(1, &dsPtr.TrPtr.VAO)
+	glb.DeleteVertexArrays(1, &dsPtr.TrPtr.VAO)
 	if dsPtr.TexPtr != nil {
 		for _, texEl := range dsPtr.TexPtr.DataElements {
 			texEl.Delete()
 		}
 	}
+	dsPtr.CtxPtr.disown(dsPtr)
 }
 
-// MakeDataShader creates DataShader with all required substructures for given ShaderSeg and mki3d.Mki3dType.
-func MakeDataShader(sPtr *Shader, mPtr *mki3d.Mki3dType) (dsPtr *DataShader, err error) {
+// MakeDataShader creates DataShader with all required substructures for given Context and mki3d.Mki3dType.
+// The DataShader uses the shaders and the texture cache of ctx and it is deleted by ctx.Close (if not deleted before).
+func MakeDataShader(ctx *Context, mPtr *mki3d.Mki3dType) (dsPtr *DataShader, err error) {
+	return MakeDataShaderWithLayout(ctx, mPtr, LayoutSeparate)
+}
+
+// MakeDataShaderWithLayout creates DataShader as MakeDataShader
+// with the vertex attributes stored in GL buffers with the given layout.
+func MakeDataShaderWithLayout(ctx *Context, mPtr *mki3d.Mki3dType, layout BufferLayout) (dsPtr *DataShader, err error) {
+	return MakeDataShaderWithOptions(ctx, mPtr, layout, nil)
+}
+
+// MakeDataShaderWithOptions creates DataShader as MakeDataShaderWithLayout
+// with the textures of the texture elements generated with the options given by texOptions (nil means DefaultTextureOptions).
+// For the same options for all textures use SameTextureOptions.
+func MakeDataShaderWithOptions(ctx *Context, mPtr *mki3d.Mki3dType, layout BufferLayout, texOptions TextureOptionsFunc) (dsPtr *DataShader, err error) {
+	return MakeDataShaderWithCache(ctx, mPtr, layout, texOptions, nil)
+}
+
+// MakeDataShaderWithCache creates DataShader as MakeDataShaderWithOptions with the textures acquired from cache.
+// A cache shared by many DataShaders makes them share the textures generated from equal definitions
+// (the textures are deleted when they are released by DeleteData of all the DataShaders).
+// If cache is nil, then the cache of ctx (shared by all DataShaders of ctx) is used.
+func MakeDataShaderWithCache(ctx *Context, mPtr *mki3d.Mki3dType, layout BufferLayout, texOptions TextureOptionsFunc, cache *TextureCache) (dsPtr *DataShader, err error) {
+	if err := ctx.check(); err != nil {
+		return nil, err
+	}
+	sPtr := ctx.ShaderPtr
+	if cache == nil {
+		cache = ctx.CachePtr
+	}
+
 	uPtr := MakeGLUni() // uniforms
 	if err != nil {
 		return nil, err
 	}
 
-	bPtr, err := MakeGLBuf(mPtr) // data buffers
+	bPtr, err := MakeGLBufWithLayout(mPtr, layout) // data buffers
 	if err != nil {
 		return nil, err
 	}
 
 	segPtr, err := MakeDataShaderSeg(sPtr.SegPtr, bPtr.SegPtr, uPtr, mPtr)
 	if err != nil {
+		bPtr.Delete()
 		return nil, err
 	}
 
 	trPtr, err := MakeDataShaderTr(sPtr.TrPtr, bPtr.TrPtr, uPtr, mPtr)
 	if err != nil {
+		bPtr.Delete()
+		glb.DeleteVertexArrays(1, &segPtr.VAO)
 		return nil, err
 	}
 
-	texPtr, err := MakeDataShaderTex(sPtr.TexPtr, uPtr, mPtr)
+	texPtr, err := MakeDataShaderTexWithCache(ctx, uPtr, mPtr, layout, texOptions, cache)
 	if err != nil {
+		bPtr.Delete()
+		glb.DeleteVertexArrays(1, &segPtr.VAO)
+		glb.DeleteVertexArrays(1, &trPtr.VAO)
 		return nil, err
 	}
 
-	ds := DataShader{SegPtr: segPtr, TrPtr: trPtr, TexPtr: texPtr, Mki3dPtr: mPtr, UniPtr: uPtr}
+	ds := DataShader{SegPtr: segPtr, TrPtr: trPtr, TexPtr: texPtr, Mki3dPtr: mPtr, UniPtr: uPtr, ShaderPtr: sPtr, Layout: layout, TexOptions: texOptions, CachePtr: cache, CtxPtr: ctx}
+	ctx.own(&ds)
 
 	return &ds, nil
 
@@ -151,9 +194,41 @@ func (ds *DataShaderTr) UniLightToShader() (err error) {
 		return errors.New("ds.UniPtr == nil // type *GLUni")
 	}
 
-	gl.UseProgram(ds.ShaderPtr.ProgramId)
-	gl.Uniform3fv(ds.ShaderPtr.LightUni, 1, &(ds.UniPtr.LightUni[0]))
-	gl.Uniform1f(ds.ShaderPtr.AmbientUni, ds.UniPtr.AmbientUni)
+	glb.UseProgram(ds.ShaderPtr.ProgramId)
+	glb.Uniform3fv(ds.ShaderPtr.LightUni, 1, &(ds.UniPtr.LightUni[0]))
+	glb.Uniform1f(ds.ShaderPtr.AmbientUni, ds.UniPtr.AmbientUni)
+
+	return nil
+}
+
+// UniClipToShader sets the clipping box uniform parameters from ds.UniPtr to ds.ShaderPtr.
+func (ds *DataShaderTr) UniClipToShader() (err error) {
+	if ds.ShaderPtr == nil {
+		return errors.New("ds.ShaderPtr == nil // type *ShaderTr")
+	}
+	if ds.UniPtr == nil {
+		return errors.New("ds.UniPtr == nil // type *GLUni")
+	}
+
+	glb.UseProgram(ds.ShaderPtr.ProgramId)
+	glb.Uniform3fv(ds.ShaderPtr.ClipMinUni, 1, &(ds.UniPtr.ClipMinUni[0]))
+	glb.Uniform3fv(ds.ShaderPtr.ClipMaxUni, 1, &(ds.UniPtr.ClipMaxUni[0]))
+
+	return nil
+}
+
+// UniClipToShader sets the clipping box uniform parameters from ds.UniPtr to ds.ShaderPtr.
+func (ds *DataShaderSeg) UniClipToShader() (err error) {
+	if ds.ShaderPtr == nil {
+		return errors.New("ds.ShaderPtr == nil // type *ShaderSeg")
+	}
+	if ds.UniPtr == nil {
+		return errors.New("ds.UniPtr == nil // type *GLUni")
+	}
+
+	glb.UseProgram(ds.ShaderPtr.ProgramId)
+	glb.Uniform3fv(ds.ShaderPtr.ClipMinUni, 1, &(ds.UniPtr.ClipMinUni[0]))
+	glb.Uniform3fv(ds.ShaderPtr.ClipMaxUni, 1, &(ds.UniPtr.ClipMaxUni[0]))
 
 	return nil
 }
@@ -167,8 +242,8 @@ func (ds *DataShaderTr) UniModelToShader() (err error) {
 		return errors.New("ds.UniPtr == nil // type *GLUni")
 	}
 
-	gl.UseProgram(ds.ShaderPtr.ProgramId)
-	gl.UniformMatrix4fv(ds.ShaderPtr.ModelUni, 1, false, &(ds.UniPtr.ModelUni[0]))
+	glb.UseProgram(ds.ShaderPtr.ProgramId)
+	glb.UniformMatrix4fv(ds.ShaderPtr.ModelUni, 1, false, &(ds.UniPtr.ModelUni[0]))
 
 	return nil
 }
@@ -182,8 +257,8 @@ func (ds *DataShaderSeg) UniModelToShader() (err error) {
 		return errors.New("ds.UniPtr == nil // type *GLUni")
 	}
 
-	gl.UseProgram(ds.ShaderPtr.ProgramId)
-	gl.UniformMatrix4fv(ds.ShaderPtr.ModelUni, 1, false, &(ds.UniPtr.ModelUni[0]))
+	glb.UseProgram(ds.ShaderPtr.ProgramId)
+	glb.UniformMatrix4fv(ds.ShaderPtr.ModelUni, 1, false, &(ds.UniPtr.ModelUni[0]))
 
 	return nil
 }
@@ -197,8 +272,8 @@ func (ds *DataShaderTr) UniViewToShader() (err error) {
 		return errors.New("ds.UniPtr == nil // type *GLUni")
 	}
 
-	gl.UseProgram(ds.ShaderPtr.ProgramId)
-	gl.UniformMatrix4fv(ds.ShaderPtr.ViewUni, 1, false, &(ds.UniPtr.ViewUni[0]))
+	glb.UseProgram(ds.ShaderPtr.ProgramId)
+	glb.UniformMatrix4fv(ds.ShaderPtr.ViewUni, 1, false, &(ds.UniPtr.ViewUni[0]))
 
 	return nil
 }
@@ -212,8 +287,8 @@ func (ds *DataShaderSeg) UniViewToShader() (err error) {
 		return errors.New("ds.UniPtr == nil // type *GLUni")
 	}
 
-	gl.UseProgram(ds.ShaderPtr.ProgramId)
-	gl.UniformMatrix4fv(ds.ShaderPtr.ViewUni, 1, false, &(ds.UniPtr.ViewUni[0]))
+	glb.UseProgram(ds.ShaderPtr.ProgramId)
+	glb.UniformMatrix4fv(ds.ShaderPtr.ViewUni, 1, false, &(ds.UniPtr.ViewUni[0]))
 
 	return nil
 }
@@ -227,8 +302,8 @@ func (ds *DataShaderTr) UniProjectionToShader() (err error) {
 		return errors.New("ds.UniPtr == nil // type *GLUni")
 	}
 
-	gl.UseProgram(ds.ShaderPtr.ProgramId)
-	gl.UniformMatrix4fv(ds.ShaderPtr.ProjectionUni, 1, false, &(ds.UniPtr.ProjectionUni[0]))
+	glb.UseProgram(ds.ShaderPtr.ProgramId)
+	glb.UniformMatrix4fv(ds.ShaderPtr.ProjectionUni, 1, false, &(ds.UniPtr.ProjectionUni[0]))
 
 	return nil
 }
@@ -242,8 +317,8 @@ func (ds *DataShaderSeg) UniProjectionToShader() (err error) {
 		return errors.New("ds.UniPtr == nil // type *GLUni")
 	}
 
-	gl.UseProgram(ds.ShaderPtr.ProgramId)
-	gl.UniformMatrix4fv(ds.ShaderPtr.ProjectionUni, 1, false, &(ds.UniPtr.ProjectionUni[0]))
+	glb.UseProgram(ds.ShaderPtr.ProgramId)
+	glb.UniformMatrix4fv(ds.ShaderPtr.ProjectionUni, 1, false, &(ds.UniPtr.ProjectionUni[0]))
 
 	return nil
 }
@@ -269,6 +344,11 @@ func (ds *DataShaderTr) InitStage() (err error) {
 		return err
 	}
 
+	err = ds.UniClipToShader() // set clipping box
+	if err != nil {
+		return err
+	}
+
 	return nil
 
 }
@@ -289,6 +369,11 @@ func (ds *DataShaderSeg) InitStage() (err error) {
 		return err
 	}
 
+	err = ds.UniClipToShader() // set clipping box
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -325,7 +410,17 @@ func (ds *DataShader) InitStage() (err error) {
 
 func (ds *DataShader) SetBackgroundColor() {
 	bg := ds.Mki3dPtr.BackgroundColor
-	gl.ClearColor(bg[0], bg[1], bg[2], 1.0)
+	glb.ClearColor(bg[0], bg[1], bg[2], 1.0)
+}
+
+// SetUniPtr makes ds and all its substructures use the uniforms referenced by uPtr.
+func (ds *DataShader) SetUniPtr(uPtr *GLUni) {
+	ds.UniPtr = uPtr
+	ds.SegPtr.UniPtr = uPtr
+	ds.TrPtr.UniPtr = uPtr
+	if ds.TexPtr != nil {
+		ds.TexPtr.UniPtr = uPtr
+	}
 }
 
 // Draw a model (triangles).
@@ -334,10 +429,10 @@ func (ds *DataShaderTr) DrawModel() {
 		return // nothing to draw
 	}
 	ds.UniModelToShader()
-	gl.UseProgram(ds.ShaderPtr.ProgramId)
-	gl.BindVertexArray(ds.VAO)
-	gl.DrawArrays(gl.TRIANGLES, 0, ds.BufPtr.VertexCount)
-	gl.BindVertexArray(0)
+	glb.UseProgram(ds.ShaderPtr.ProgramId)
+	glb.BindVertexArray(ds.VAO)
+	glb.DrawArrays(gl.TRIANGLES, 0, ds.BufPtr.VertexCount)
+	glb.BindVertexArray(0)
 }
 
 // Draw a model (segments).
@@ -346,10 +441,10 @@ func (ds *DataShaderSeg) DrawModel() {
 		return // nothing to draw
 	}
 	ds.UniModelToShader()
-	gl.UseProgram(ds.ShaderPtr.ProgramId)
-	gl.BindVertexArray(ds.VAO)
-	gl.DrawArrays(gl.LINES, 0, ds.BufPtr.VertexCount)
-	gl.BindVertexArray(0)
+	glb.UseProgram(ds.ShaderPtr.ProgramId)
+	glb.BindVertexArray(ds.VAO)
+	glb.DrawArrays(gl.LINES, 0, ds.BufPtr.VertexCount)
+	glb.BindVertexArray(0)
 }
 
 // Draw a model (segments and triangles).
@@ -398,26 +493,36 @@ func (ds *DataShaderTr) InitVAO() (err error) {
 		return errors.New("ds.ShaderPtr == nil // type *ShaderTr")
 	}
 
-	gl.UseProgram(ds.ShaderPtr.ProgramId)
-	gl.GenVertexArrays(1, &(ds.VAO))
-	gl.BindVertexArray(ds.VAO)
+	glb.UseProgram(ds.ShaderPtr.ProgramId)
+	glb.GenVertexArrays(1, &(ds.VAO))
+	glb.BindVertexArray(ds.VAO)
+
+	if ds.BufPtr.Layout == LayoutInterleaved {
+		// bind positions, normals and colors from the single buffer
+		glb.BindBuffer(gl.ARRAY_BUFFER, ds.BufPtr.VertexBuf)
+		vertexAttribInterleaved(ds.ShaderPtr.PositionAttr, 3, mki3d.TriangleInterleavedSize, 0)
+		vertexAttribInterleaved(ds.ShaderPtr.NormalAttr, 3, mki3d.TriangleInterleavedSize, 3)
+		vertexAttribInterleaved(ds.ShaderPtr.ColorAttr, 3, mki3d.TriangleInterleavedSize, 6)
+		glb.BindVertexArray(0) // unbind VAO
+		return nil
+	}
 
 	// bind vertex positions
-	gl.BindBuffer(gl.ARRAY_BUFFER, ds.BufPtr.PositionBuf)
-	gl.EnableVertexAttribArray(ds.ShaderPtr.PositionAttr)
-	gl.VertexAttribPointer(ds.ShaderPtr.PositionAttr, 3, gl.FLOAT, false, 0 /* stride */, gl.PtrOffset(0))
+	glb.BindBuffer(gl.ARRAY_BUFFER, ds.BufPtr.PositionBuf)
+	glb.EnableVertexAttribArray(ds.ShaderPtr.PositionAttr)
+	glb.VertexAttribPointer(ds.ShaderPtr.PositionAttr, 3, gl.FLOAT, false, 0 /* stride */, gl.PtrOffset(0))
 
 	// bind vertex colors
-	gl.BindBuffer(gl.ARRAY_BUFFER, ds.BufPtr.ColorBuf)
-	gl.EnableVertexAttribArray(ds.ShaderPtr.ColorAttr)
-	gl.VertexAttribPointer(ds.ShaderPtr.ColorAttr, 3, gl.FLOAT, false, 0 /* stride */, gl.PtrOffset(0))
+	glb.BindBuffer(gl.ARRAY_BUFFER, ds.BufPtr.ColorBuf)
+	glb.EnableVertexAttribArray(ds.ShaderPtr.ColorAttr)
+	glb.VertexAttribPointer(ds.ShaderPtr.ColorAttr, 3, gl.FLOAT, false, 0 /* stride */, gl.PtrOffset(0))
 
 	// bind vertex normals
-	gl.BindBuffer(gl.ARRAY_BUFFER, ds.BufPtr.NormalBuf)
-	gl.EnableVertexAttribArray(ds.ShaderPtr.NormalAttr)
-	gl.VertexAttribPointer(ds.ShaderPtr.NormalAttr, 3, gl.FLOAT, false, 0 /* stride */, gl.PtrOffset(0))
+	glb.BindBuffer(gl.ARRAY_BUFFER, ds.BufPtr.NormalBuf)
+	glb.EnableVertexAttribArray(ds.ShaderPtr.NormalAttr)
+	glb.VertexAttribPointer(ds.ShaderPtr.NormalAttr, 3, gl.FLOAT, false, 0 /* stride */, gl.PtrOffset(0))
 
-	gl.BindVertexArray(0) // unbind VAO
+	glb.BindVertexArray(0) // unbind VAO
 
 	return nil
 
@@ -437,21 +542,30 @@ func (ds *DataShaderSeg) InitVAO() (err error) {
 		return errors.New("ds.ShaderPtr == nil // type *ShaderTr")
 	}
 
-	gl.UseProgram(ds.ShaderPtr.ProgramId)
-	gl.GenVertexArrays(1, &(ds.VAO))
-	gl.BindVertexArray(ds.VAO)
+	glb.UseProgram(ds.ShaderPtr.ProgramId)
+	glb.GenVertexArrays(1, &(ds.VAO))
+	glb.BindVertexArray(ds.VAO)
+
+	if ds.BufPtr.Layout == LayoutInterleaved {
+		// bind positions and colors from the single buffer
+		glb.BindBuffer(gl.ARRAY_BUFFER, ds.BufPtr.VertexBuf)
+		vertexAttribInterleaved(ds.ShaderPtr.PositionAttr, 3, mki3d.SegmentInterleavedSize, 0)
+		vertexAttribInterleaved(ds.ShaderPtr.ColorAttr, 3, mki3d.SegmentInterleavedSize, 3)
+		glb.BindVertexArray(0) // unbind VAO
+		return nil
+	}
 
 	// bind vertex positions
-	gl.BindBuffer(gl.ARRAY_BUFFER, ds.BufPtr.PositionBuf)
-	gl.EnableVertexAttribArray(ds.ShaderPtr.PositionAttr)
-	gl.VertexAttribPointer(ds.ShaderPtr.PositionAttr, 3, gl.FLOAT, false, 0 /* stride */, gl.PtrOffset(0))
+	glb.BindBuffer(gl.ARRAY_BUFFER, ds.BufPtr.PositionBuf)
+	glb.EnableVertexAttribArray(ds.ShaderPtr.PositionAttr)
+	glb.VertexAttribPointer(ds.ShaderPtr.PositionAttr, 3, gl.FLOAT, false, 0 /* stride */, gl.PtrOffset(0))
 
 	// bind vertex colors
-	gl.BindBuffer(gl.ARRAY_BUFFER, ds.BufPtr.ColorBuf)
-	gl.EnableVertexAttribArray(ds.ShaderPtr.ColorAttr)
-	gl.VertexAttribPointer(ds.ShaderPtr.ColorAttr, 3, gl.FLOAT, false, 0 /* stride */, gl.PtrOffset(0))
+	glb.BindBuffer(gl.ARRAY_BUFFER, ds.BufPtr.ColorBuf)
+	glb.EnableVertexAttribArray(ds.ShaderPtr.ColorAttr)
+	glb.VertexAttribPointer(ds.ShaderPtr.ColorAttr, 3, gl.FLOAT, false, 0 /* stride */, gl.PtrOffset(0))
 
-	gl.BindVertexArray(0) // unbind VAO
+	glb.BindVertexArray(0) // unbind VAO
 
 	return nil
 
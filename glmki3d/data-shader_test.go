@@ -0,0 +1,308 @@
+package glmki3d
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/mki1967/go-mki3d/glmki3d/glfake"
+	"github.com/mki1967/go-mki3d/mki3d"
+	"strings"
+	"testing"
+)
+
+var _ GLBackend = (*glfake.FakeGL)(nil)
+
+// useFakeGL makes glmki3d use a new FakeGL until the end of the test t
+func useFakeGL(t *testing.T) *glfake.FakeGL {
+	fake := glfake.MakeFakeGL()
+	previous := SetGLBackend(fake)
+	t.Cleanup(func() { SetGLBackend(previous) })
+	return fake
+}
+
+// testModel returns mki3d data with segments, triangles and two texture elements with different textures
+func testModel() *mki3d.Mki3dType {
+	box := mki3d.MakeBoxTextured(mki3d.Vector3dType{1, 1, 1}, mki3d.PrimitiveOptions{})
+	plane := mki3d.MakePlaneTextured(2, 2, mki3d.PrimitiveOptions{})
+	return &mki3d.Mki3dType{
+		Model: mki3d.ModelType{
+			Segments:  mki3d.MakeAxes(1, mki3d.PrimitiveOptions{}).Segments,
+			Triangles: mki3d.MakeBox(mki3d.Vector3dType{1, 2, 3}, mki3d.PrimitiveOptions{}).Triangles,
+		},
+		Projection: mki3d.ProjectionType{ZNear: 0.25, ZFar: 100, ZoomY: 4},
+		Texture: &mki3d.TextureType{Elements: mki3d.TextureElementsType{
+			{Def: mki3d.TexturionDefType{Label: "red", R: "1", G: "0", B: "0", A: "1"}, TexturedTriangles: box},
+			{Def: mki3d.TexturionDefType{Label: "green", R: "0", G: "1", B: "0", A: "1"}, TexturedTriangles: plane},
+		}},
+	}
+}
+
+// makeTestDataShader returns a context and a DataShader of testModel made in it
+func makeTestDataShader(t *testing.T) (*Context, *DataShader) {
+	ctx, err := MakeContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds, err := MakeDataShader(ctx, testModel())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctx, ds
+}
+
+// checkBuffer checks that buffer exists in fake and holds vertexCount vertices of size float32 values
+func checkBuffer(t *testing.T, fake *glfake.FakeGL, name string, buffer uint32, vertexCount int32, size int) {
+	t.Helper()
+	b, ok := fake.Buffers[buffer]
+	if !ok {
+		t.Errorf("%s: buffer %d does not exist", name, buffer)
+		return
+	}
+	if len(b.Data) != int(vertexCount)*size*4 {
+		t.Errorf("%s: %d bytes in buffer, want %d", name, len(b.Data), int(vertexCount)*size*4)
+	}
+}
+
+// checkAttribute checks that attribute attr of the vertex array vao is enabled and reads from buffer
+func checkAttribute(t *testing.T, fake *glfake.FakeGL, name string, vao, attr, buffer uint32) {
+	t.Helper()
+	va, ok := fake.VertexArrays[vao]
+	if !ok {
+		t.Errorf("%s: vertex array %d does not exist", name, vao)
+		return
+	}
+	a, ok := va.Attributes[attr]
+	if !ok || !a.Enabled || a.Buffer != buffer {
+		t.Errorf("%s: attribute %d = %+v, want enabled with buffer %d", name, attr, a, buffer)
+	}
+}
+
+func TestMakeDataShader(t *testing.T) {
+	fake := useFakeGL(t)
+	ctx, ds := makeTestDataShader(t)
+	defer ctx.Close()
+
+	// programs of the context (the programs generating the textures are deleted)
+	if len(fake.Programs) != 3 {
+		t.Errorf("%d programs, want 3", len(fake.Programs))
+	}
+	for _, program := range []uint32{ds.ShaderPtr.SegPtr.ProgramId, ds.ShaderPtr.TrPtr.ProgramId, ds.ShaderPtr.TexPtr.ProgramId} {
+		if p, ok := fake.Programs[program]; !ok || !p.Linked {
+			t.Errorf("program %d is not linked", program)
+		}
+	}
+	if len(fake.Shaders) != 0 {
+		t.Errorf("%d shaders not deleted after linking", len(fake.Shaders))
+	}
+
+	seg, tr := ds.SegPtr.BufPtr, ds.TrPtr.BufPtr
+	if seg.VertexCount != int32(2*len(ds.Mki3dPtr.Model.Segments)) || tr.VertexCount != int32(3*len(ds.Mki3dPtr.Model.Triangles)) {
+		t.Errorf("vertex counts %d, %d", seg.VertexCount, tr.VertexCount)
+	}
+	checkBuffer(t, fake, "segment positions", seg.PositionBuf, seg.VertexCount, 3)
+	checkBuffer(t, fake, "segment colors", seg.ColorBuf, seg.VertexCount, 3)
+	checkBuffer(t, fake, "triangle positions", tr.PositionBuf, tr.VertexCount, 3)
+	checkBuffer(t, fake, "triangle normals", tr.NormalBuf, tr.VertexCount, 3)
+	checkBuffer(t, fake, "triangle colors", tr.ColorBuf, tr.VertexCount, 3)
+	checkAttribute(t, fake, "segments", ds.SegPtr.VAO, ds.ShaderPtr.SegPtr.PositionAttr, seg.PositionBuf)
+	checkAttribute(t, fake, "segments", ds.SegPtr.VAO, ds.ShaderPtr.SegPtr.ColorAttr, seg.ColorBuf)
+	checkAttribute(t, fake, "triangles", ds.TrPtr.VAO, ds.ShaderPtr.TrPtr.PositionAttr, tr.PositionBuf)
+	checkAttribute(t, fake, "triangles", ds.TrPtr.VAO, ds.ShaderPtr.TrPtr.NormalAttr, tr.NormalBuf)
+	checkAttribute(t, fake, "triangles", ds.TrPtr.VAO, ds.ShaderPtr.TrPtr.ColorAttr, tr.ColorBuf)
+
+	if len(ds.TexPtr.DataElements) != 2 {
+		t.Fatalf("%d texture elements, want 2", len(ds.TexPtr.DataElements))
+	}
+	texShader := ds.ShaderPtr.TexPtr
+	for _, el := range ds.TexPtr.DataElements {
+		checkBuffer(t, fake, "textured positions", el.PositionBuf, el.VertexCount, 3)
+		checkBuffer(t, fake, "textured normals", el.NormalBuf, el.VertexCount, 3)
+		checkBuffer(t, fake, "textured UVs", el.TexUVBuf, el.VertexCount, 2)
+		checkAttribute(t, fake, "textured", el.VAO, texShader.PositionAttr, el.PositionBuf)
+		checkAttribute(t, fake, "textured", el.VAO, texShader.NormalAttr, el.NormalBuf)
+		checkAttribute(t, fake, "textured", el.VAO, texShader.TexAttr, el.TexUVBuf)
+		tex, ok := fake.Textures[el.Texture]
+		if !ok || tex.Width != texSize || tex.Height != texSize || !tex.Mipmaps {
+			t.Errorf("texture %d = %+v, want %dx%d with mipmaps", el.Texture, tex, texSize, texSize)
+		}
+	}
+	if ds.TexPtr.DataElements[0].Texture == ds.TexPtr.DataElements[1].Texture {
+		t.Error("different definitions share a texture")
+	}
+
+	// 2 segment, 3 triangle and 2*3 textured buffers and the hBuffer of the context
+	if len(fake.Buffers) != 12 {
+		t.Errorf("%d buffers, want 12", len(fake.Buffers))
+	}
+	// the VAOs of the segments, the triangles and the texture elements (the VAOs generating the textures are deleted)
+	if len(fake.VertexArrays) != 4 {
+		t.Errorf("%d vertex arrays, want 4", len(fake.VertexArrays))
+	}
+	if len(fake.Textures) != 2 {
+		t.Errorf("%d textures, want 2", len(fake.Textures))
+	}
+}
+
+// checkUniform checks the value of the uniform name of program
+func checkUniform(t *testing.T, fake *glfake.FakeGL, program uint32, name string, want []float32) {
+	t.Helper()
+	got := fake.UniformValue(program, name)
+	if len(got) != len(want) {
+		t.Errorf("program %d: %s = %v, want %v", program, name, got, want)
+		return
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("program %d: %s = %v, want %v", program, name, got, want)
+			return
+		}
+	}
+}
+
+func TestDrawStage(t *testing.T) {
+	fake := useFakeGL(t)
+	ctx, ds := makeTestDataShader(t)
+	defer ctx.Close()
+
+	ds.UniPtr.ProjectionUni = ProjectionMatrix(ds.Mki3dPtr.Projection, 800, 600)
+	ds.UniPtr.ViewUni = mgl32.Translate3D(0, 0, -10)
+	ds.UniPtr.ModelUni = mgl32.Translate3D(1, 2, 3)
+	ds.UniPtr.LightUni = mgl32.Vec3{0, 1, 0}
+	ds.UniPtr.AmbientUni = 0.25
+
+	fake.Draws = nil                   // forget the draws generating the textures
+	fake.BindTexture(gl.TEXTURE_2D, 0) // the generated textures are not bound before the stage
+	ds.DrawStage()
+
+	segProgram, trProgram, texProgram := ds.ShaderPtr.SegPtr.ProgramId, ds.ShaderPtr.TrPtr.ProgramId, ds.ShaderPtr.TexPtr.ProgramId
+	want := []glfake.FakeDraw{
+		{Mode: gl.TRIANGLES, Count: ds.TrPtr.BufPtr.VertexCount, Program: trProgram, VertexArray: ds.TrPtr.VAO},
+		{Mode: gl.LINES, Count: ds.SegPtr.BufPtr.VertexCount, Program: segProgram, VertexArray: ds.SegPtr.VAO},
+	}
+	for _, el := range ds.TexPtr.DataElements {
+		want = append(want, glfake.FakeDraw{Mode: gl.TRIANGLES, Count: el.VertexCount, Program: texProgram, VertexArray: el.VAO, Texture: el.Texture})
+	}
+	if len(fake.Draws) != len(want) {
+		t.Fatalf("draws %+v, want %+v", fake.Draws, want)
+	}
+	for i := range want {
+		if fake.Draws[i] != want[i] {
+			t.Errorf("draw %d = %+v, want %+v", i, fake.Draws[i], want[i])
+		}
+	}
+
+	uni := ds.UniPtr
+	for _, program := range []uint32{segProgram, trProgram, texProgram} {
+		checkUniform(t, fake, program, "projection", uni.ProjectionUni[:])
+		checkUniform(t, fake, program, "view", uni.ViewUni[:])
+		checkUniform(t, fake, program, "model", uni.ModelUni[:])
+		checkUniform(t, fake, program, "clipMin", uni.ClipMinUni[:])
+		checkUniform(t, fake, program, "clipMax", uni.ClipMaxUni[:])
+	}
+	for _, program := range []uint32{trProgram, texProgram} {
+		checkUniform(t, fake, program, "light", uni.LightUni[:])
+		checkUniform(t, fake, program, "ambient", []float32{uni.AmbientUni})
+	}
+	checkUniform(t, fake, texProgram, "texSampler", []float32{0})
+}
+
+func TestDeleteData(t *testing.T) {
+	fake := useFakeGL(t)
+	ctx, ds := makeTestDataShader(t)
+	defer ctx.Close()
+
+	ds.DeleteData()
+
+	// only the resources of the context are left
+	if len(fake.VertexArrays) != 0 || len(fake.Textures) != 0 || len(fake.Renderbuffers) != 0 {
+		t.Errorf("%d vertex arrays, %d textures and %d renderbuffers not deleted",
+			len(fake.VertexArrays), len(fake.Textures), len(fake.Renderbuffers))
+	}
+	if _, ok := fake.Buffers[ctx.hBufferId]; !ok || len(fake.Buffers) != 1 {
+		t.Errorf("buffers %v, want only the hBuffer %d", fake.Buffers, ctx.hBufferId)
+	}
+	if !fake.Framebuffers[ctx.frameBufferId] || len(fake.Framebuffers) != 1 {
+		t.Errorf("framebuffers %v, want only %d", fake.Framebuffers, ctx.frameBufferId)
+	}
+	if len(fake.Programs) != 3 {
+		t.Errorf("%d programs, want 3", len(fake.Programs))
+	}
+	if ctx.CachePtr.Len() != 0 {
+		t.Errorf("%d textures left in the cache", ctx.CachePtr.Len())
+	}
+}
+
+// badDefModel returns testModel with the texture definition of the second element,
+// which does not compile (as reported by rejectBadDefs)
+func badDefModel() *mki3d.Mki3dType {
+	m := testModel()
+	m.Texture.Elements[1].Def.G = "bad(x)"
+	return m
+}
+
+// rejectBadDefs makes the shaders calling undefined function bad fail to compile in fake
+func rejectBadDefs(fake *glfake.FakeGL) {
+	fake.CompileError = func(source string) string {
+		if strings.Contains(source, "bad(") {
+			return "error: 'bad' : no matching overloaded function found"
+		}
+		return ""
+	}
+}
+
+// dataObjects returns the number of the objects in fake except the shared buffers of ctx (made with the first texture)
+func dataObjects(fake *glfake.FakeGL, ctx *Context) int {
+	n := fake.LiveObjects()
+	if ctx.hBufferId != 0 {
+		n--
+	}
+	if ctx.frameBufferId != 0 {
+		n--
+	}
+	return n
+}
+
+func TestMakeDataShaderTexBadDef(t *testing.T) {
+	fake := useFakeGL(t)
+	ctx, err := MakeContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+	rejectBadDefs(fake)
+
+	cache := MakeTextureCache(ctx)
+	liveObjects := dataObjects(fake, ctx)
+	texPtr, err := MakeDataShaderTexWithCache(ctx, MakeGLUni(), badDefModel(), LayoutSeparate, nil, cache)
+	if err == nil || texPtr != nil {
+		t.Fatal("bad texture definition accepted")
+	}
+	if !strings.Contains(err.Error(), "no matching overloaded function") {
+		t.Errorf("error %q without the compilation log", err)
+	}
+	if dataObjects(fake, ctx) != liveObjects {
+		t.Errorf("%d objects left in GL: buffers %v, vertex arrays %v, textures %v, shaders %v",
+			dataObjects(fake, ctx)-liveObjects, fake.Buffers, fake.VertexArrays, fake.Textures, fake.Shaders)
+	}
+	if cache.Len() != 0 {
+		t.Errorf("%d textures left in the cache", cache.Len())
+	}
+}
+
+func TestMakeDataShaderBadDef(t *testing.T) {
+	fake := useFakeGL(t)
+	ctx, err := MakeContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+	rejectBadDefs(fake)
+
+	liveObjects := dataObjects(fake, ctx)
+	if _, err := MakeDataShader(ctx, badDefModel()); err == nil {
+		t.Fatal("bad texture definition accepted")
+	}
+	if dataObjects(fake, ctx) != liveObjects || ctx.CachePtr.Len() != 0 || len(ctx.owned) != 0 {
+		t.Errorf("%d objects left in GL, %d textures in the cache, %d owned by the context",
+			dataObjects(fake, ctx)-liveObjects, ctx.CachePtr.Len(), len(ctx.owned))
+	}
+}
@@ -21,14 +21,17 @@ uniform float ambient;
  
 /* output to fragment shader */
 out vec3 texUVS; // (u,v,shade)
+out vec3 vWorld;
 void main() {
     /* compute shaded color */
     vec4 modelNormal=model*vec4(normal, 0.0);
     float shade= abs( dot( modelNormal.xyz, light ) ); // diffuse factor
     texUVS.xy=texAttr;
     texUVS.z=(ambient+(1.0-ambient)*shade); // shading scaling factor
-    /* compute projected position */
-    gl_Position = projection*view*model*vec4(position, 1.0);
+    /* compute world and projected position */
+    vec4 worldPosition = model*vec4(position, 1.0);
+    vWorld = worldPosition.xyz;
+    gl_Position = projection*view*worldPosition;
 }
 ` + "\x00"
 
@@ -37,11 +40,16 @@ var fragmentShaderTex = `
 #version 330
 /* input from vertex shader */
 in vec3 texUVS;
+in vec3 vWorld;
 /* uniforms */
 uniform sampler2D texSampler;
+uniform vec3 clipMin;
+uniform vec3 clipMax;
 /* fragment color output */
 out vec4 outputColor;
 void main() {
+    /* discard fragments outside the clipping box */
+    if( any(lessThan(vWorld, clipMin)) || any(greaterThan(vWorld, clipMax)) ) discard;
      outputColor = vec4(texUVS.z*texture2D(texSampler, texUVS.xy).rgb, 1.0) ;
     // outputColor = vec4(texture2D(texSampler, texUVS.xy).rgb, 1.0) ;
     // outputColor = texture2D(texSampler, texUVS.xy) ;
@@ -65,12 +73,19 @@ type ShaderTex struct {
 	LightUni      int32
 	AmbientUni    int32
 	TexSamplerUni int32
+	ClipMinUni    int32
+	ClipMaxUni    int32
 }
 
 // MakeShaderTex compiles  mki3d shader for drawing textured triangles and
 // returns pointer to its newly created  ShaderTex structure
 func MakeShaderTex() (shaderPtr *ShaderTex, err error) {
-	program, err := NewProgram(vertexShaderTex, fragmentShaderTex)
+	return makeShaderTex(vertexShaderTex)
+}
+
+// makeShaderTex compiles mki3d shader for drawing textured triangles with given vertex shader source.
+func makeShaderTex(vertexShaderSource string) (shaderPtr *ShaderTex, err error) {
+	program, err := NewProgram(vertexShaderSource, fragmentShaderTex)
 	if err != nil {
 		return nil, err
 	}
@@ -80,19 +95,21 @@ func MakeShaderTex() (shaderPtr *ShaderTex, err error) {
 	// set ProgramId
 	shader.ProgramId = program
 
-	// gl.BindFragDataLocation(program, 0, gl.Str("outputColor\x00")) // test
+	// glb.BindFragDataLocation(program, 0, gl.Str("outputColor\x00")) // test
 
 	// set attributes
-	shader.PositionAttr = 0 //uint32(gl.GetAttribLocation(program, gl.Str("position\x00")))
-	shader.NormalAttr = 1   // uint32(gl.GetAttribLocation(program, gl.Str("normal\x00")))
-	shader.TexAttr = 2      // uint32(gl.GetAttribLocation(program, gl.Str("texAttr\x00")))
+	shader.PositionAttr = 0 //uint32(glb.GetAttribLocation(program, gl.Str("position\x00")))
+	shader.NormalAttr = 1   // uint32(glb.GetAttribLocation(program, gl.Str("normal\x00")))
+	shader.TexAttr = 2      // uint32(glb.GetAttribLocation(program, gl.Str("texAttr\x00")))
 
 	// set uniforms
-	shader.ProjectionUni = gl.GetUniformLocation(program, gl.Str("projection\x00"))
-	shader.ViewUni = gl.GetUniformLocation(program, gl.Str("view\x00"))
-	shader.ModelUni = gl.GetUniformLocation(program, gl.Str("model\x00"))
-	shader.LightUni = gl.GetUniformLocation(program, gl.Str("light\x00"))
-	shader.AmbientUni = gl.GetUniformLocation(program, gl.Str("ambient\x00"))
-	shader.TexSamplerUni = gl.GetUniformLocation(program, gl.Str("texSampler\x00"))
+	shader.ProjectionUni = glb.GetUniformLocation(program, gl.Str("projection\x00"))
+	shader.ViewUni = glb.GetUniformLocation(program, gl.Str("view\x00"))
+	shader.ModelUni = glb.GetUniformLocation(program, gl.Str("model\x00"))
+	shader.LightUni = glb.GetUniformLocation(program, gl.Str("light\x00"))
+	shader.AmbientUni = glb.GetUniformLocation(program, gl.Str("ambient\x00"))
+	shader.TexSamplerUni = glb.GetUniformLocation(program, gl.Str("texSampler\x00"))
+	shader.ClipMinUni = glb.GetUniformLocation(program, gl.Str("clipMin\x00"))
+	shader.ClipMaxUni = glb.GetUniformLocation(program, gl.Str("clipMax\x00"))
 	return &shader, nil
 }
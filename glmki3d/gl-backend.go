@@ -0,0 +1,247 @@
+package glmki3d
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"unsafe"
+)
+
+/* GL functions used by glmki3d */
+
+// GLBackend is the interface of the GL functions used by glmki3d.
+// The methods have the signatures of the functions of the package github.com/go-gl/gl/v3.3-core/gl.
+// (The helpers gl.Ptr, gl.PtrOffset, gl.Str, gl.Strs and gl.GoStr do not need GL and are called directly.)
+type GLBackend interface {
+	ActiveTexture(texture uint32)
+	AttachShader(program uint32, shader uint32)
+	BindBuffer(target uint32, buffer uint32)
+	BindFragDataLocation(program uint32, color uint32, name *uint8)
+	BindFramebuffer(target uint32, framebuffer uint32)
+	BindRenderbuffer(target uint32, renderbuffer uint32)
+	BindTexture(target uint32, texture uint32)
+	BindVertexArray(array uint32)
+	BlitFramebuffer(srcX0, srcY0, srcX1, srcY1, dstX0, dstY0, dstX1, dstY1 int32, mask uint32, filter uint32)
+	BufferData(target uint32, size int, data unsafe.Pointer, usage uint32)
+	BufferSubData(target uint32, offset int, size int, data unsafe.Pointer)
+	CheckFramebufferStatus(target uint32) uint32
+	Clear(mask uint32)
+	ClearColor(red float32, green float32, blue float32, alpha float32)
+	CompileShader(shader uint32)
+	CopyTexSubImage2D(target uint32, level int32, xoffset int32, yoffset int32, x int32, y int32, width int32, height int32)
+	CreateProgram() uint32
+	CreateShader(xtype uint32) uint32
+	DeleteBuffers(n int32, buffers *uint32)
+	DeleteFramebuffers(n int32, framebuffers *uint32)
+	DeleteProgram(program uint32)
+	DeleteRenderbuffers(n int32, renderbuffers *uint32)
+	DeleteShader(shader uint32)
+	DeleteTextures(n int32, textures *uint32)
+	DeleteVertexArrays(n int32, arrays *uint32)
+	Disable(cap uint32)
+	DisableVertexAttribArray(index uint32)
+	DrawArrays(mode uint32, first int32, count int32)
+	DrawArraysInstanced(mode uint32, first int32, count int32, instancecount int32)
+	Enable(cap uint32)
+	EnableVertexAttribArray(index uint32)
+	FramebufferRenderbuffer(target uint32, attachment uint32, renderbuffertarget uint32, renderbuffer uint32)
+	FramebufferTexture2D(target uint32, attachment uint32, textarget uint32, texture uint32, level int32)
+	GenBuffers(n int32, buffers *uint32)
+	GenFramebuffers(n int32, framebuffers *uint32)
+	GenRenderbuffers(n int32, renderbuffers *uint32)
+	GenTextures(n int32, textures *uint32)
+	GenVertexArrays(n int32, arrays *uint32)
+	GenerateMipmap(target uint32)
+	GetAttribLocation(program uint32, name *uint8) int32
+	GetFloatv(pname uint32, data *float32)
+	GetIntegerv(pname uint32, data *int32)
+	GetProgramInfoLog(program uint32, bufSize int32, length *int32, infoLog *uint8)
+	GetProgramiv(program uint32, pname uint32, params *int32)
+	GetShaderInfoLog(shader uint32, bufSize int32, length *int32, infoLog *uint8)
+	GetShaderiv(shader uint32, pname uint32, params *int32)
+	GetStringi(name uint32, index uint32) *uint8
+	GetTexImage(target uint32, level int32, format uint32, xtype uint32, pixels unsafe.Pointer)
+	GetTexLevelParameteriv(target uint32, level int32, pname uint32, params *int32)
+	GetUniformLocation(program uint32, name *uint8) int32
+	IsEnabled(cap uint32) bool
+	LinkProgram(program uint32)
+	PixelStorei(pname uint32, param int32)
+	ReadPixels(x int32, y int32, width int32, height int32, format uint32, xtype uint32, pixels unsafe.Pointer)
+	RenderbufferStorage(target uint32, internalformat uint32, width int32, height int32)
+	RenderbufferStorageMultisample(target uint32, samples int32, internalformat uint32, width int32, height int32)
+	ShaderSource(shader uint32, count int32, xstring **uint8, length *int32)
+	TexImage2D(target uint32, level int32, internalformat int32, width int32, height int32, border int32, format uint32, xtype uint32, pixels unsafe.Pointer)
+	TexParameterf(target uint32, pname uint32, param float32)
+	TexParameteri(target uint32, pname uint32, param int32)
+	Uniform1f(location int32, v0 float32)
+	Uniform1i(location int32, v0 int32)
+	Uniform3fv(location int32, count int32, value *float32)
+	UniformMatrix4fv(location int32, count int32, transpose bool, value *float32)
+	UseProgram(program uint32)
+	VertexAttribDivisor(index uint32, divisor uint32)
+	VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, pointer unsafe.Pointer)
+	Viewport(x int32, y int32, width int32, height int32)
+}
+
+// GoGLBackend is the GLBackend calling the functions of github.com/go-gl/gl/v3.3-core/gl
+type GoGLBackend struct{}
+
+func (GoGLBackend) ActiveTexture(texture uint32)               { gl.ActiveTexture(texture) }
+func (GoGLBackend) AttachShader(program uint32, shader uint32) { gl.AttachShader(program, shader) }
+func (GoGLBackend) BindBuffer(target uint32, buffer uint32)    { gl.BindBuffer(target, buffer) }
+func (GoGLBackend) BindFragDataLocation(program uint32, color uint32, name *uint8) {
+	gl.BindFragDataLocation(program, color, name)
+}
+func (GoGLBackend) BindFramebuffer(target uint32, framebuffer uint32) {
+	gl.BindFramebuffer(target, framebuffer)
+}
+func (GoGLBackend) BindRenderbuffer(target uint32, renderbuffer uint32) {
+	gl.BindRenderbuffer(target, renderbuffer)
+}
+func (GoGLBackend) BindTexture(target uint32, texture uint32) { gl.BindTexture(target, texture) }
+func (GoGLBackend) BindVertexArray(array uint32)              { gl.BindVertexArray(array) }
+func (GoGLBackend) BlitFramebuffer(srcX0, srcY0, srcX1, srcY1, dstX0, dstY0, dstX1, dstY1 int32, mask uint32, filter uint32) {
+	gl.BlitFramebuffer(srcX0, srcY0, srcX1, srcY1, dstX0, dstY0, dstX1, dstY1, mask, filter)
+}
+func (GoGLBackend) BufferData(target uint32, size int, data unsafe.Pointer, usage uint32) {
+	gl.BufferData(target, size, data, usage)
+}
+func (GoGLBackend) BufferSubData(target uint32, offset int, size int, data unsafe.Pointer) {
+	gl.BufferSubData(target, offset, size, data)
+}
+func (GoGLBackend) CheckFramebufferStatus(target uint32) uint32 {
+	return gl.CheckFramebufferStatus(target)
+}
+func (GoGLBackend) Clear(mask uint32) { gl.Clear(mask) }
+func (GoGLBackend) ClearColor(red float32, green float32, blue float32, alpha float32) {
+	gl.ClearColor(red, green, blue, alpha)
+}
+func (GoGLBackend) CompileShader(shader uint32) { gl.CompileShader(shader) }
+func (GoGLBackend) CopyTexSubImage2D(target uint32, level int32, xoffset int32, yoffset int32, x int32, y int32, width int32, height int32) {
+	gl.CopyTexSubImage2D(target, level, xoffset, yoffset, x, y, width, height)
+}
+func (GoGLBackend) CreateProgram() uint32                  { return gl.CreateProgram() }
+func (GoGLBackend) CreateShader(xtype uint32) uint32       { return gl.CreateShader(xtype) }
+func (GoGLBackend) DeleteBuffers(n int32, buffers *uint32) { gl.DeleteBuffers(n, buffers) }
+func (GoGLBackend) DeleteFramebuffers(n int32, framebuffers *uint32) {
+	gl.DeleteFramebuffers(n, framebuffers)
+}
+func (GoGLBackend) DeleteProgram(program uint32) { gl.DeleteProgram(program) }
+func (GoGLBackend) DeleteRenderbuffers(n int32, renderbuffers *uint32) {
+	gl.DeleteRenderbuffers(n, renderbuffers)
+}
+func (GoGLBackend) DeleteShader(shader uint32)                 { gl.DeleteShader(shader) }
+func (GoGLBackend) DeleteTextures(n int32, textures *uint32)   { gl.DeleteTextures(n, textures) }
+func (GoGLBackend) DeleteVertexArrays(n int32, arrays *uint32) { gl.DeleteVertexArrays(n, arrays) }
+func (GoGLBackend) Disable(cap uint32)                         { gl.Disable(cap) }
+func (GoGLBackend) DisableVertexAttribArray(index uint32)      { gl.DisableVertexAttribArray(index) }
+func (GoGLBackend) DrawArrays(mode uint32, first int32, count int32) {
+	gl.DrawArrays(mode, first, count)
+}
+func (GoGLBackend) DrawArraysInstanced(mode uint32, first int32, count int32, instancecount int32) {
+	gl.DrawArraysInstanced(mode, first, count, instancecount)
+}
+func (GoGLBackend) Enable(cap uint32)                    { gl.Enable(cap) }
+func (GoGLBackend) EnableVertexAttribArray(index uint32) { gl.EnableVertexAttribArray(index) }
+func (GoGLBackend) FramebufferRenderbuffer(target uint32, attachment uint32, renderbuffertarget uint32, renderbuffer uint32) {
+	gl.FramebufferRenderbuffer(target, attachment, renderbuffertarget, renderbuffer)
+}
+func (GoGLBackend) FramebufferTexture2D(target uint32, attachment uint32, textarget uint32, texture uint32, level int32) {
+	gl.FramebufferTexture2D(target, attachment, textarget, texture, level)
+}
+func (GoGLBackend) GenBuffers(n int32, buffers *uint32) { gl.GenBuffers(n, buffers) }
+func (GoGLBackend) GenFramebuffers(n int32, framebuffers *uint32) {
+	gl.GenFramebuffers(n, framebuffers)
+}
+func (GoGLBackend) GenRenderbuffers(n int32, renderbuffers *uint32) {
+	gl.GenRenderbuffers(n, renderbuffers)
+}
+func (GoGLBackend) GenTextures(n int32, textures *uint32)   { gl.GenTextures(n, textures) }
+func (GoGLBackend) GenVertexArrays(n int32, arrays *uint32) { gl.GenVertexArrays(n, arrays) }
+func (GoGLBackend) GenerateMipmap(target uint32)            { gl.GenerateMipmap(target) }
+func (GoGLBackend) GetAttribLocation(program uint32, name *uint8) int32 {
+	return gl.GetAttribLocation(program, name)
+}
+func (GoGLBackend) GetFloatv(pname uint32, data *float32) { gl.GetFloatv(pname, data) }
+func (GoGLBackend) GetIntegerv(pname uint32, data *int32) { gl.GetIntegerv(pname, data) }
+func (GoGLBackend) GetProgramInfoLog(program uint32, bufSize int32, length *int32, infoLog *uint8) {
+	gl.GetProgramInfoLog(program, bufSize, length, infoLog)
+}
+func (GoGLBackend) GetProgramiv(program uint32, pname uint32, params *int32) {
+	gl.GetProgramiv(program, pname, params)
+}
+func (GoGLBackend) GetShaderInfoLog(shader uint32, bufSize int32, length *int32, infoLog *uint8) {
+	gl.GetShaderInfoLog(shader, bufSize, length, infoLog)
+}
+func (GoGLBackend) GetShaderiv(shader uint32, pname uint32, params *int32) {
+	gl.GetShaderiv(shader, pname, params)
+}
+func (GoGLBackend) GetStringi(name uint32, index uint32) *uint8 { return gl.GetStringi(name, index) }
+func (GoGLBackend) GetTexImage(target uint32, level int32, format uint32, xtype uint32, pixels unsafe.Pointer) {
+	gl.GetTexImage(target, level, format, xtype, pixels)
+}
+func (GoGLBackend) GetTexLevelParameteriv(target uint32, level int32, pname uint32, params *int32) {
+	gl.GetTexLevelParameteriv(target, level, pname, params)
+}
+func (GoGLBackend) GetUniformLocation(program uint32, name *uint8) int32 {
+	return gl.GetUniformLocation(program, name)
+}
+func (GoGLBackend) IsEnabled(cap uint32) bool             { return gl.IsEnabled(cap) }
+func (GoGLBackend) LinkProgram(program uint32)            { gl.LinkProgram(program) }
+func (GoGLBackend) PixelStorei(pname uint32, param int32) { gl.PixelStorei(pname, param) }
+func (GoGLBackend) ReadPixels(x int32, y int32, width int32, height int32, format uint32, xtype uint32, pixels unsafe.Pointer) {
+	gl.ReadPixels(x, y, width, height, format, xtype, pixels)
+}
+func (GoGLBackend) RenderbufferStorage(target uint32, internalformat uint32, width int32, height int32) {
+	gl.RenderbufferStorage(target, internalformat, width, height)
+}
+func (GoGLBackend) RenderbufferStorageMultisample(target uint32, samples int32, internalformat uint32, width int32, height int32) {
+	gl.RenderbufferStorageMultisample(target, samples, internalformat, width, height)
+}
+func (GoGLBackend) ShaderSource(shader uint32, count int32, xstring **uint8, length *int32) {
+	gl.ShaderSource(shader, count, xstring, length)
+}
+func (GoGLBackend) TexImage2D(target uint32, level int32, internalformat int32, width int32, height int32, border int32, format uint32, xtype uint32, pixels unsafe.Pointer) {
+	gl.TexImage2D(target, level, internalformat, width, height, border, format, xtype, pixels)
+}
+func (GoGLBackend) TexParameterf(target uint32, pname uint32, param float32) {
+	gl.TexParameterf(target, pname, param)
+}
+func (GoGLBackend) TexParameteri(target uint32, pname uint32, param int32) {
+	gl.TexParameteri(target, pname, param)
+}
+func (GoGLBackend) Uniform1f(location int32, v0 float32) { gl.Uniform1f(location, v0) }
+func (GoGLBackend) Uniform1i(location int32, v0 int32)   { gl.Uniform1i(location, v0) }
+func (GoGLBackend) Uniform3fv(location int32, count int32, value *float32) {
+	gl.Uniform3fv(location, count, value)
+}
+func (GoGLBackend) UniformMatrix4fv(location int32, count int32, transpose bool, value *float32) {
+	gl.UniformMatrix4fv(location, count, transpose, value)
+}
+func (GoGLBackend) UseProgram(program uint32) { gl.UseProgram(program) }
+func (GoGLBackend) VertexAttribDivisor(index uint32, divisor uint32) {
+	gl.VertexAttribDivisor(index, divisor)
+}
+func (GoGLBackend) VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, pointer unsafe.Pointer) {
+	gl.VertexAttribPointer(index, size, xtype, normalized, stride, pointer)
+}
+func (GoGLBackend) Viewport(x int32, y int32, width int32, height int32) {
+	gl.Viewport(x, y, width, height)
+}
+
+// glb is the GLBackend called by glmki3d (also by the functions, which do not take a Context)
+var glb GLBackend = GoGLBackend{}
+
+// SetGLBackend makes glmki3d call the GL functions of backend (GoGLBackend if backend is nil)
+// and returns the previous backend. For testing without GL context use glfake.FakeGL.
+//
+// The backend is global for the process and it is not per Context: it is used for all GL contexts
+// (as the functions of the package gl, which act on the GL context current in the calling thread).
+// SetGLBackend is not safe for concurrent use - call it only when no glmki3d function is running
+// (e.g. not from tests running with t.Parallel).
+func SetGLBackend(backend GLBackend) (previous GLBackend) {
+	previous = glb
+	if backend == nil {
+		backend = GoGLBackend{}
+	}
+	glb = backend
+	return previous
+}
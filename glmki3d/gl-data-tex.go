@@ -15,23 +15,31 @@ import (
 type GLDataTexEl struct {
 	// texture object
 	Texture uint32
+	Def     mki3d.TexturionDefType // the definition from which Texture has been generated
+	Options TextureOptions         // the (normalized) options with which Texture has been generated
+	// if not nil, Texture has been acquired from the cache (and is released to it)
+	CachePtr *TextureCache
+	// context in which Texture has been generated
+	CtxPtr *Context
 	// buffer objects in GL
 	// triangles:
 	VertexCount int32  // the last argument for gl.DrawArrays
 	PositionBuf uint32 // positions of the endpoints
 	NormalBuf   uint32 // normals of the endpooints
 	TexUVBuf    uint32 // UV coordinates of the endpoints
+	// interleaved positions, normals and UV coordinates (used instead of the above buffers for LayoutInterleaved)
+	Layout    BufferLayout
+	VertexBuf uint32
 	// VAO for the TexturedElement
 	VAO uint32
 }
 
 // Delete the texture and buffers in GL, when they are not needed any more
 func (glData *GLDataTexEl) Delete() {
-	vbo := []uint32{glData.PositionBuf, glData.NormalBuf, glData.TexUVBuf}
-	gl.DeleteBuffers(3, &vbo[0])
-	textures := []uint32{glData.Texture}
-	gl.DeleteTextures(1, &textures[0])
-	gl.DeleteVertexArrays(1, &glData.VAO)
+	vbo := []uint32{glData.PositionBuf, glData.NormalBuf, glData.TexUVBuf, glData.VertexBuf} // zeros are ignored by GL
+	glb.DeleteBuffers(4, &vbo[0])
+	glData.releaseTexture()
+	glb.DeleteVertexArrays(1, &glData.VAO)
 
 }
 
@@ -42,6 +50,15 @@ func (glData *GLDataTexEl) LoadTriangleBufs(texEl *mki3d.TextureElementType) {
 	if glData.VertexCount == 0 {
 		return // do not create empty buffers
 	}
+
+	if glData.Layout == LayoutInterleaved {
+		data := texEl.TexturedTriangles.GetInterleavedArray()
+		glb.BindBuffer(gl.ARRAY_BUFFER, glData.VertexBuf)
+		glb.BufferData(gl.ARRAY_BUFFER, len(data)*4 /* 4 bytes per float32 */, gl.Ptr(data), gl.STATIC_DRAW)
+		glb.BindBuffer(gl.ARRAY_BUFFER, 0) // unbind
+		return
+	}
+
 	dataPos := triangles.GetPositionArrays()
 	// fmt.Printf("dataPos = %v\n", dataPos) //// test
 	dataNor := triangles.GetNormalArrays()
@@ -49,67 +66,113 @@ func (glData *GLDataTexEl) LoadTriangleBufs(texEl *mki3d.TextureElementType) {
 	dataUV := texEl.TexturedTriangles.GetUVArrays()
 	// fmt.Printf("dataUV = %v\n", dataUV) //// test
 	/* transfer data to the GL memory */
-	gl.BindBuffer(gl.ARRAY_BUFFER, glData.PositionBuf)
-	gl.BufferData(gl.ARRAY_BUFFER, len(dataPos)*4 /* 4 bytes per float32 */, gl.Ptr(dataPos), gl.STATIC_DRAW)
+	glb.BindBuffer(gl.ARRAY_BUFFER, glData.PositionBuf)
+	glb.BufferData(gl.ARRAY_BUFFER, len(dataPos)*4 /* 4 bytes per float32 */, gl.Ptr(dataPos), gl.STATIC_DRAW)
+
+	glb.BindBuffer(gl.ARRAY_BUFFER, glData.NormalBuf)
+	glb.BufferData(gl.ARRAY_BUFFER, len(dataNor)*4 /* 4 bytes per float32 */, gl.Ptr(dataNor), gl.STATIC_DRAW)
+
+	glb.BindBuffer(gl.ARRAY_BUFFER, glData.TexUVBuf)
+	glb.BufferData(gl.ARRAY_BUFFER, len(dataUV)*4 /* 4 bytes per float32 */, gl.Ptr(dataUV), gl.STATIC_DRAW)
 
-	gl.BindBuffer(gl.ARRAY_BUFFER, glData.NormalBuf)
-	gl.BufferData(gl.ARRAY_BUFFER, len(dataNor)*4 /* 4 bytes per float32 */, gl.Ptr(dataNor), gl.STATIC_DRAW)
+	glb.BindBuffer(gl.ARRAY_BUFFER, 0) // unbind
+}
 
-	gl.BindBuffer(gl.ARRAY_BUFFER, glData.TexUVBuf)
-	gl.BufferData(gl.ARRAY_BUFFER, len(dataUV)*4 /* 4 bytes per float32 */, gl.Ptr(dataUV), gl.STATIC_DRAW)
+// MakeGLDataTexEl either returns pointer to a new GLDataTexEl or an error
+func MakeGLDataTexEl(ctx *Context, texEl *mki3d.TextureElementType, shaderPtr *ShaderTex) (*GLDataTexEl, error) {
+	return MakeGLDataTexElWithLayout(ctx, texEl, shaderPtr, LayoutSeparate)
+}
 
-	gl.BindBuffer(gl.ARRAY_BUFFER, 0) // unbind
+// MakeGLDataTexElWithLayout either returns pointer to a new GLDataTexEl with the given layout of buffers or an error
+func MakeGLDataTexElWithLayout(ctx *Context, texEl *mki3d.TextureElementType, shaderPtr *ShaderTex, layout BufferLayout) (*GLDataTexEl, error) {
+	return MakeGLDataTexElWithOptions(ctx, texEl, shaderPtr, layout, DefaultTextureOptions)
 }
 
-// MakeGLBufTr either returns pointer to a new GLBufTr or an error
-func MakeGLDataTexEl(texEl *mki3d.TextureElementType, shaderPtr *ShaderTex) (*GLDataTexEl, error) {
+// MakeGLDataTexElWithOptions works as MakeGLDataTexElWithLayout with the texture generated with the given options
+func MakeGLDataTexElWithOptions(ctx *Context, texEl *mki3d.TextureElementType, shaderPtr *ShaderTex, layout BufferLayout, options TextureOptions) (*GLDataTexEl, error) {
+	return MakeGLDataTexElWithCache(ctx, texEl, shaderPtr, layout, options, nil)
+}
+
+// MakeGLDataTexElWithCache works as MakeGLDataTexElWithOptions with the texture acquired from cache (if cache is not nil)
+func MakeGLDataTexElWithCache(ctx *Context, texEl *mki3d.TextureElementType, shaderPtr *ShaderTex, layout BufferLayout, options TextureOptions, cache *TextureCache) (*GLDataTexEl, error) {
+	if err := ctx.check(); err != nil {
+		return nil, err
+	}
 	if shaderPtr == nil {
 		return nil, errors.New("shaderPtr == nil // type *ShaderTex")
 	}
 
-	var glData GLDataTexEl
-	var vbo [3]uint32 // 5 is the number of buffers
-	gl.GenBuffers(3, &vbo[0])
-	// TO DO: test for error ...
-
-	// assign buffer ids from vbo array
-	glData.PositionBuf = vbo[0]
-	glData.NormalBuf = vbo[1]
-	glData.TexUVBuf = vbo[2]
-
-	// load data from mki3dData
-	glData.LoadTriangleBufs(texEl)
+	glData := GLDataTexEl{Layout: layout, CachePtr: cache, CtxPtr: ctx}
 
-	//// Make texture
+	//// Make texture (before the buffers, so that nothing is left in GL if it fails)
 
-	texture, err := GenerateTexture(texEl.Def)
+	options = options.normalized()
+	texture, err := glData.generateTexture(texEl.Def, options)
 	if err != nil {
 		return nil, err
 	}
 
 	glData.Texture = texture
+	glData.Def = texEl.Def
+	glData.Options = options
+
+	glData.genBuffers()
+
+	// load data from mki3dData
+	glData.LoadTriangleBufs(texEl)
 
 	/// make and init VAO
+	glData.InitVAO(shaderPtr)
+
+	return &glData, nil
+}
+
+// genBuffers generates GL buffers of glData for glData.Layout
+func (glData *GLDataTexEl) genBuffers() {
+	if glData.Layout == LayoutInterleaved {
+		glb.GenBuffers(1, &glData.VertexBuf)
+		return
+	}
+	var vbo [3]uint32 // 3 is the number of buffers
+	glb.GenBuffers(3, &vbo[0])
+	// TO DO: test for error ...
+
+	// assign buffer ids from vbo array
+	glData.PositionBuf = vbo[0]
+	glData.NormalBuf = vbo[1]
+	glData.TexUVBuf = vbo[2]
+}
+
+// InitVAO makes and inits the VAO of glData for the shader shaderPtr.
+func (glData *GLDataTexEl) InitVAO(shaderPtr *ShaderTex) {
+	glb.UseProgram(shaderPtr.ProgramId)
+	glb.GenVertexArrays(1, &(glData.VAO))
+	glb.BindVertexArray(glData.VAO)
+
+	if glData.Layout == LayoutInterleaved {
+		// bind positions, normals and UV from the single buffer
+		glb.BindBuffer(gl.ARRAY_BUFFER, glData.VertexBuf)
+		vertexAttribInterleaved(shaderPtr.PositionAttr, 3, mki3d.TexturedInterleavedSize, 0)
+		vertexAttribInterleaved(shaderPtr.NormalAttr, 3, mki3d.TexturedInterleavedSize, 3)
+		vertexAttribInterleaved(shaderPtr.TexAttr, 2, mki3d.TexturedInterleavedSize, 6)
+		glb.BindVertexArray(0) // unbind VAO
+		return
+	}
 
-	gl.UseProgram(shaderPtr.ProgramId)
-	gl.GenVertexArrays(1, &(glData.VAO))
-	gl.BindVertexArray(glData.VAO)
 	// bind vertex positions
-	gl.BindBuffer(gl.ARRAY_BUFFER, glData.PositionBuf)
-	gl.EnableVertexAttribArray(shaderPtr.PositionAttr)
-	gl.VertexAttribPointer(shaderPtr.PositionAttr, 3, gl.FLOAT, false, 0 /* stride */, gl.PtrOffset(0))
+	glb.BindBuffer(gl.ARRAY_BUFFER, glData.PositionBuf)
+	glb.EnableVertexAttribArray(shaderPtr.PositionAttr)
+	glb.VertexAttribPointer(shaderPtr.PositionAttr, 3, gl.FLOAT, false, 0 /* stride */, gl.PtrOffset(0))
 
 	// bind vertex UV
-	gl.BindBuffer(gl.ARRAY_BUFFER, glData.TexUVBuf)
-	gl.EnableVertexAttribArray(shaderPtr.TexAttr)
-	gl.VertexAttribPointer(shaderPtr.TexAttr, 2, gl.FLOAT, false, 0 /* stride */, gl.PtrOffset(0))
+	glb.BindBuffer(gl.ARRAY_BUFFER, glData.TexUVBuf)
+	glb.EnableVertexAttribArray(shaderPtr.TexAttr)
+	glb.VertexAttribPointer(shaderPtr.TexAttr, 2, gl.FLOAT, false, 0 /* stride */, gl.PtrOffset(0))
 
 	// bind vertex normals
-	gl.BindBuffer(gl.ARRAY_BUFFER, glData.NormalBuf)
-	gl.EnableVertexAttribArray(shaderPtr.NormalAttr)
-	gl.VertexAttribPointer(shaderPtr.NormalAttr, 3, gl.FLOAT, false, 0 /* stride */, gl.PtrOffset(0))
+	glb.BindBuffer(gl.ARRAY_BUFFER, glData.NormalBuf)
+	glb.EnableVertexAttribArray(shaderPtr.NormalAttr)
+	glb.VertexAttribPointer(shaderPtr.NormalAttr, 3, gl.FLOAT, false, 0 /* stride */, gl.PtrOffset(0))
 
-	gl.BindVertexArray(0) // unbind VAO
-
-	return &glData, nil
+	glb.BindVertexArray(0) // unbind VAO
 }
@@ -8,14 +8,28 @@ import (
 
 // references to the objects defining the shape and parameters of mki3d object
 
+// BufferLayout describes how the vertex attributes are stored in GL buffers
+type BufferLayout int
+
+const (
+	// LayoutSeparate - each attribute is stored in its own buffer (stride 0)
+	LayoutSeparate BufferLayout = iota
+	// LayoutInterleaved - all attributes are stored in a single buffer (see mki3d.TriangleInterleavedSize etc.)
+	LayoutInterleaved
+)
+
 // GLBufTr contains references to GL triangle buffers for triangle shader's input attributes
 type GLBufTr struct {
 	// buffer objects in GL
 	// triangles:
 	VertexCount int32 // the last argument for gl.DrawArrays
+	Capacity    int32 // the number of vertices for which the buffers are allocated
 	PositionBuf uint32
 	NormalBuf   uint32
 	ColorBuf    uint32
+	// interleaved positions, normals and colors (used instead of the above buffers for LayoutInterleaved)
+	Layout    BufferLayout
+	VertexBuf uint32
 }
 
 // GLBufSeg contains references to GL segment buffers for segment shader's input attributes
@@ -23,8 +37,12 @@ type GLBufSeg struct {
 	// buffer objects in GL
 	// segments:
 	VertexCount int32 // the last argument for gl.DrawArrays
+	Capacity    int32 // the number of vertices for which the buffers are allocated
 	PositionBuf uint32
 	ColorBuf    uint32
+	// interleaved positions and colors (used instead of the above buffers for LayoutInterleaved)
+	Layout    BufferLayout
+	VertexBuf uint32
 }
 
 // GLBuf contains references to GL buffers for shaders' input attributes
@@ -38,14 +56,14 @@ type GLBuf struct {
 
 // Delete the buffers in GL, when they are not needed any more
 func (glBuf *GLBufTr) Delete() {
-	vbo := []uint32{glBuf.PositionBuf, glBuf.NormalBuf, glBuf.ColorBuf}
-	gl.DeleteBuffers(3, &vbo[0])
+	vbo := []uint32{glBuf.PositionBuf, glBuf.NormalBuf, glBuf.ColorBuf, glBuf.VertexBuf} // zeros are ignored by GL
+	glb.DeleteBuffers(4, &vbo[0])
 }
 
 // Delete the buffers in GL, when they are not needed any more
 func (glBuf *GLBufSeg) Delete() {
-	vbo := []uint32{glBuf.PositionBuf, glBuf.ColorBuf}
-	gl.DeleteBuffers(2, &vbo[0])
+	vbo := []uint32{glBuf.PositionBuf, glBuf.ColorBuf, glBuf.VertexBuf} // zeros are ignored by GL
+	glb.DeleteBuffers(3, &vbo[0])
 }
 
 // Delete the buffers in GL, when they are not needed any more
@@ -56,54 +74,94 @@ func (glBuf *GLBuf) Delete() {
 
 // LoadTriangleBufs loads data from mki3dData to the GL buffers referenced by glBuf (and fills glBuf.NormalBuf with computed normals)
 func (glBuf *GLBufTr) LoadTriangleBufs(mki3dData *mki3d.Mki3dType) {
-	glBuf.VertexCount = int32(3 * len(mki3dData.Model.Triangles))
+	glBuf.LoadTriangles(mki3dData.Model.Triangles)
+}
+
+// LoadTriangles loads triangles to the GL buffers referenced by glBuf (with computed normals)
+func (glBuf *GLBufTr) LoadTriangles(triangles mki3d.TrianglesType) {
+	glBuf.VertexCount = int32(3 * len(triangles))
+	glBuf.Capacity = glBuf.VertexCount
 	if glBuf.VertexCount == 0 {
 		return // do not create empty buffers
 	}
-	dataPos := mki3dData.Model.Triangles.GetPositionArrays()
-	dataCol := mki3dData.Model.Triangles.GetColorArrays()
-	dataNor := mki3dData.Model.Triangles.GetNormalArrays()
+
+	if glBuf.Layout == LayoutInterleaved {
+		data := triangles.GetInterleavedArray()
+		glb.BindBuffer(gl.ARRAY_BUFFER, glBuf.VertexBuf)
+		glb.BufferData(gl.ARRAY_BUFFER, len(data)*4 /* 4 bytes per float32 */, gl.Ptr(data), gl.STATIC_DRAW)
+		glb.BindBuffer(gl.ARRAY_BUFFER, 0) // unbind
+		return
+	}
+
+	dataPos := triangles.GetPositionArrays()
+	dataCol := triangles.GetColorArrays()
+	dataNor := triangles.GetNormalArrays()
 
 	/* transfer data to the GL memory */
-	gl.BindBuffer(gl.ARRAY_BUFFER, glBuf.PositionBuf)
-	gl.BufferData(gl.ARRAY_BUFFER, len(dataPos)*4 /* 4 bytes per float32 */, gl.Ptr(dataPos), gl.STATIC_DRAW)
+	glb.BindBuffer(gl.ARRAY_BUFFER, glBuf.PositionBuf)
+	glb.BufferData(gl.ARRAY_BUFFER, len(dataPos)*4 /* 4 bytes per float32 */, gl.Ptr(dataPos), gl.STATIC_DRAW)
 
-	gl.BindBuffer(gl.ARRAY_BUFFER, glBuf.ColorBuf)
-	gl.BufferData(gl.ARRAY_BUFFER, len(dataCol)*4 /* 4 bytes per float32 */, gl.Ptr(dataCol), gl.STATIC_DRAW)
+	glb.BindBuffer(gl.ARRAY_BUFFER, glBuf.ColorBuf)
+	glb.BufferData(gl.ARRAY_BUFFER, len(dataCol)*4 /* 4 bytes per float32 */, gl.Ptr(dataCol), gl.STATIC_DRAW)
 
-	gl.BindBuffer(gl.ARRAY_BUFFER, glBuf.NormalBuf)
-	gl.BufferData(gl.ARRAY_BUFFER, len(dataNor)*4 /* 4 bytes per float32 */, gl.Ptr(dataNor), gl.STATIC_DRAW)
+	glb.BindBuffer(gl.ARRAY_BUFFER, glBuf.NormalBuf)
+	glb.BufferData(gl.ARRAY_BUFFER, len(dataNor)*4 /* 4 bytes per float32 */, gl.Ptr(dataNor), gl.STATIC_DRAW)
 
-	gl.BindBuffer(gl.ARRAY_BUFFER, 0) // unbind
+	glb.BindBuffer(gl.ARRAY_BUFFER, 0) // unbind
 }
 
 // LoadSegmentBufs loads data from mki3dData to the GL buffers referenced by glBuf
 func (glBuf *GLBufSeg) LoadSegmentBufs(mki3dData *mki3d.Mki3dType) {
-	glBuf.VertexCount = int32(2 * len(mki3dData.Model.Segments))
+	glBuf.LoadSegments(mki3dData.Model.Segments)
+}
+
+// LoadSegments loads segments to the GL buffers referenced by glBuf
+func (glBuf *GLBufSeg) LoadSegments(segments mki3d.SegmentsType) {
+	glBuf.VertexCount = int32(2 * len(segments))
+	glBuf.Capacity = glBuf.VertexCount
 	if glBuf.VertexCount == 0 {
 		return // do not create empty buffers
 	}
-	dataPos := mki3dData.Model.Segments.GetPositionArrays()
-	dataCol := mki3dData.Model.Segments.GetColorArrays()
+
+	if glBuf.Layout == LayoutInterleaved {
+		data := segments.GetInterleavedArray()
+		glb.BindBuffer(gl.ARRAY_BUFFER, glBuf.VertexBuf)
+		glb.BufferData(gl.ARRAY_BUFFER, len(data)*4 /* 4 bytes per float32 */, gl.Ptr(data), gl.STATIC_DRAW)
+		glb.BindBuffer(gl.ARRAY_BUFFER, 0) // unbind
+		return
+	}
+
+	dataPos := segments.GetPositionArrays()
+	dataCol := segments.GetColorArrays()
 	/* transfer data to the GL memory */
-	gl.BindBuffer(gl.ARRAY_BUFFER, glBuf.PositionBuf)
-	gl.BufferData(gl.ARRAY_BUFFER, len(dataPos)*4 /* 4 bytes per float32 */, gl.Ptr(dataPos), gl.STATIC_DRAW)
-	gl.BindBuffer(gl.ARRAY_BUFFER, glBuf.ColorBuf)
-	gl.BufferData(gl.ARRAY_BUFFER, len(dataCol)*4 /* 4 bytes per float32 */, gl.Ptr(dataCol), gl.STATIC_DRAW)
-	gl.BindBuffer(gl.ARRAY_BUFFER, 0) // unbind
+	glb.BindBuffer(gl.ARRAY_BUFFER, glBuf.PositionBuf)
+	glb.BufferData(gl.ARRAY_BUFFER, len(dataPos)*4 /* 4 bytes per float32 */, gl.Ptr(dataPos), gl.STATIC_DRAW)
+	glb.BindBuffer(gl.ARRAY_BUFFER, glBuf.ColorBuf)
+	glb.BufferData(gl.ARRAY_BUFFER, len(dataCol)*4 /* 4 bytes per float32 */, gl.Ptr(dataCol), gl.STATIC_DRAW)
+	glb.BindBuffer(gl.ARRAY_BUFFER, 0) // unbind
 }
 
 // MakeGLBufTr either returns pointer to a new GLBufTr or an error
 func MakeGLBufTr(mki3dData *mki3d.Mki3dType) (glBufPtr *GLBufTr, err error) {
-	var glBuf GLBufTr
-	var vbo [3]uint32 // 5 is the number of buffers
-	gl.GenBuffers(3, &vbo[0])
-	// TO DO: test for error ...
+	return MakeGLBufTrWithLayout(mki3dData, LayoutSeparate)
+}
 
-	// assign buffer ids from vbo array
-	glBuf.PositionBuf = vbo[0]
-	glBuf.NormalBuf = vbo[1]
-	glBuf.ColorBuf = vbo[2]
+// MakeGLBufTrWithLayout either returns pointer to a new GLBufTr with the given layout or an error
+func MakeGLBufTrWithLayout(mki3dData *mki3d.Mki3dType, layout BufferLayout) (glBufPtr *GLBufTr, err error) {
+	glBuf := GLBufTr{Layout: layout}
+
+	if layout == LayoutInterleaved {
+		glb.GenBuffers(1, &glBuf.VertexBuf)
+	} else {
+		var vbo [3]uint32 // 3 is the number of buffers
+		glb.GenBuffers(3, &vbo[0])
+		// TO DO: test for error ...
+
+		// assign buffer ids from vbo array
+		glBuf.PositionBuf = vbo[0]
+		glBuf.NormalBuf = vbo[1]
+		glBuf.ColorBuf = vbo[2]
+	}
 
 	// load data from mki3dData
 	glBuf.LoadTriangleBufs(mki3dData)
@@ -113,29 +171,54 @@ func MakeGLBufTr(mki3dData *mki3d.Mki3dType) (glBufPtr *GLBufTr, err error) {
 
 // MakeGLBufSeg either returns pointer to a new GLBufSeg or an error
 func MakeGLBufSeg(mki3dData *mki3d.Mki3dType) (glBufPtr *GLBufSeg, err error) {
-	var glBuf GLBufSeg
-	var vbo [2]uint32 // 2 is the number of buffers
-	gl.GenBuffers(2, &vbo[0])
-	// TO DO: test for error ...
+	return MakeGLBufSegFromSegments(mki3dData.Model.Segments)
+}
 
-	// assign buffer ids from vbo array
-	glBuf.PositionBuf = vbo[0]
-	glBuf.ColorBuf = vbo[1]
+// MakeGLBufSegWithLayout either returns pointer to a new GLBufSeg with the given layout or an error
+func MakeGLBufSegWithLayout(mki3dData *mki3d.Mki3dType, layout BufferLayout) (glBufPtr *GLBufSeg, err error) {
+	return MakeGLBufSegFromSegmentsWithLayout(mki3dData.Model.Segments, layout)
+}
 
-	// load data from mki3dData
-	glBuf.LoadSegmentBufs(mki3dData)
+// MakeGLBufSegFromSegments either returns pointer to a new GLBufSeg loaded with segments or an error
+func MakeGLBufSegFromSegments(segments mki3d.SegmentsType) (glBufPtr *GLBufSeg, err error) {
+	return MakeGLBufSegFromSegmentsWithLayout(segments, LayoutSeparate)
+}
+
+// MakeGLBufSegFromSegmentsWithLayout either returns pointer to a new GLBufSeg with the given layout loaded with segments or an error
+func MakeGLBufSegFromSegmentsWithLayout(segments mki3d.SegmentsType, layout BufferLayout) (glBufPtr *GLBufSeg, err error) {
+	glBuf := GLBufSeg{Layout: layout}
+
+	if layout == LayoutInterleaved {
+		glb.GenBuffers(1, &glBuf.VertexBuf)
+	} else {
+		var vbo [2]uint32 // 2 is the number of buffers
+		glb.GenBuffers(2, &vbo[0])
+		// TO DO: test for error ...
+
+		// assign buffer ids from vbo array
+		glBuf.PositionBuf = vbo[0]
+		glBuf.ColorBuf = vbo[1]
+	}
+
+	// load data from segments
+	glBuf.LoadSegments(segments)
 
 	return &glBuf, nil
 }
 
 // MakeGLBuf either returns pointer to a new GLBuf or an error
 func MakeGLBuf(mki3dData *mki3d.Mki3dType) (glBufPtr *GLBuf, err error) {
+	return MakeGLBufWithLayout(mki3dData, LayoutSeparate)
+}
 
-	glSegBufPtr, err := MakeGLBufSeg(mki3dData)
+// MakeGLBufWithLayout either returns pointer to a new GLBuf with the given layout or an error
+func MakeGLBufWithLayout(mki3dData *mki3d.Mki3dType, layout BufferLayout) (glBufPtr *GLBuf, err error) {
+
+	glSegBufPtr, err := MakeGLBufSegWithLayout(mki3dData, layout)
 	if err != nil {
 		return nil, err
 	}
-	glTrBufPtr, err := MakeGLBufTr(mki3dData)
+	glTrBufPtr, err := MakeGLBufTrWithLayout(mki3dData, layout)
 	if err != nil {
 		return nil, err
 	}
@@ -143,3 +226,10 @@ func MakeGLBuf(mki3dData *mki3d.Mki3dType) (glBufPtr *GLBuf, err error) {
 	glBuf := GLBuf{TrPtr: glTrBufPtr, SegPtr: glSegBufPtr}
 	return &glBuf, nil
 }
+
+// vertexAttribInterleaved enables attribute attr and sets it to size float32 values
+// at offset (in float32 values) in the vertices of stride float32 values of the currently bound ARRAY_BUFFER
+func vertexAttribInterleaved(attr uint32, size, stride, offset int) {
+	glb.EnableVertexAttribArray(attr)
+	glb.VertexAttribPointer(attr, int32(size), gl.FLOAT, false, int32(stride*4) /* 4 bytes per float32 */, gl.PtrOffset(offset*4))
+}
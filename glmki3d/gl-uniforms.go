@@ -4,6 +4,7 @@ import (
 	// "errors"
 	"github.com/go-gl/mathgl/mgl32"
 	"github.com/mki1967/go-mki3d/mki3d"
+	"math"
 )
 
 // GLUni - values of parameters to be stored in shaders' uniforms
@@ -13,6 +14,8 @@ type GLUni struct {
 	ModelUni      mgl32.Mat4
 	LightUni      mgl32.Vec3
 	AmbientUni    float32
+	ClipMinUni    mgl32.Vec3 // minimal corner of the clipping box (in world coordinates)
+	ClipMaxUni    mgl32.Vec3 // maximal corner of the clipping box (in world coordinates)
 }
 
 // SetSimple  sets GLUni for simple drawing directly in clipping space
@@ -23,9 +26,16 @@ func (glUni *GLUni) SetSimple() {
 	glUni.ModelUni = mgl32.Ident4()
 	glUni.LightUni = mgl32.Vec3{0, 0, 1}
 	glUni.AmbientUni = 1
+	glUni.SetNoClip()
 
 }
 
+// SetNoClip sets the clipping box of glUni to the whole space (i.e. nothing is clipped)
+func (glUni *GLUni) SetNoClip() {
+	glUni.ClipMinUni = mgl32.Vec3{-math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32}
+	glUni.ClipMaxUni = mgl32.Vec3{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32}
+}
+
 // MakeGLUni makes GLUni with values of uniforms set to simple default values  and returns a pointer to it.
 func MakeGLUni() *GLUni {
 	var glUni GLUni
@@ -111,3 +121,10 @@ func (glUni *GLUni) SetLightFromMki3d(mki3dData *mki3d.Mki3dType) {
 	glUni.LightUni = mgl32.Vec3(mki3dData.Light.Vector)
 	glUni.AmbientUni = mki3dData.Light.AmbientFraction
 }
+
+// Sets the clipping box of glUni based on the data from mki3dData.
+// The function panics if mki3dData == nil
+func (glUni *GLUni) SetClipFromMki3d(mki3dData *mki3d.Mki3dType) {
+	glUni.ClipMinUni = mgl32.Vec3(mki3dData.ClipMinVector)
+	glUni.ClipMaxUni = mgl32.Vec3(mki3dData.ClipMaxVector)
+}
@@ -0,0 +1,710 @@
+// Package glfake contains FakeGL - a fake GL backend for testing glmki3d (and the code using it) without GL context.
+package glfake
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"unsafe"
+)
+
+// maxTextureMaxAnisotropy is the constant of the extension GL_EXT_texture_filter_anisotropic
+const maxTextureMaxAnisotropy = 0x84FF
+
+// FakeBuffer is a buffer object of FakeGL
+type FakeBuffer struct {
+	Data  []byte // copy of the data loaded to the buffer
+	Usage uint32
+}
+
+// FakeAttribute is a vertex attribute of FakeVertexArray
+type FakeAttribute struct {
+	Buffer     uint32 // buffer bound to ARRAY_BUFFER when the pointer was set
+	Size       int32
+	Type       uint32
+	Normalized bool
+	Stride     int32
+	Offset     uintptr
+	Enabled    bool
+	Divisor    uint32
+}
+
+// FakeVertexArray is a vertex array object of FakeGL
+type FakeVertexArray struct {
+	Attributes map[uint32]*FakeAttribute // by the attribute index
+}
+
+// FakeShader is a shader object of FakeGL
+type FakeShader struct {
+	Type     uint32
+	Source   string
+	Compiled bool
+	Log      string // info log of the failed compilation
+}
+
+// FakeProgram is a program object of FakeGL.
+// The locations of the attributes and uniforms are assigned in the order of the first queries.
+type FakeProgram struct {
+	Shaders    []uint32
+	Linked     bool
+	Attributes map[string]int32
+	Uniforms   map[string]int32
+	Values     map[int32][]float32 // values of the uniforms by their locations
+}
+
+// FakeTexture is a texture object of FakeGL
+type FakeTexture struct {
+	Width, Height int32
+	Parameters    map[uint32]float32
+	Mipmaps       bool // GenerateMipmap has been called
+}
+
+// FakeDraw is a draw call recorded by FakeGL
+type FakeDraw struct {
+	Mode        uint32
+	First       int32
+	Count       int32
+	Instances   int32 // 0 for DrawArrays
+	Program     uint32
+	VertexArray uint32
+	Texture     uint32 // texture bound to TEXTURE_2D
+}
+
+// FakeGL is a glmki3d.GLBackend, which records the calls and tracks the GL objects and state without GL,
+// so that glmki3d can be tested headlessly:
+//
+//	fake := glfake.MakeFakeGL()
+//	previous := glmki3d.SetGLBackend(fake)
+//	defer glmki3d.SetGLBackend(previous)
+//
+// The shaders compile unless CompileError says otherwise, the programs always link and the framebuffers are always complete.
+type FakeGL struct {
+	Calls []string // names of the called functions
+
+	Buffers       map[uint32]*FakeBuffer
+	VertexArrays  map[uint32]*FakeVertexArray
+	Shaders       map[uint32]*FakeShader
+	Programs      map[uint32]*FakeProgram
+	Textures      map[uint32]*FakeTexture
+	Framebuffers  map[uint32]bool
+	Renderbuffers map[uint32]bool
+
+	Draws []FakeDraw
+
+	Bindings      map[uint32]uint32 // objects bound to the targets (ARRAY_BUFFER, TEXTURE_2D, READ_FRAMEBUFFER, ...)
+	VertexArray   uint32            // bound vertex array object
+	Program       uint32            // program in use
+	ActiveTexUnit uint32
+	Capabilities  map[uint32]bool // enabled capabilities
+	Viewport4     [4]int32
+	ClearColor4   [4]float32
+	PixelStore    map[uint32]int32 // parameters set by PixelStorei (PACK_ALIGNMENT, UNPACK_ALIGNMENT, ...)
+
+	MaxTextureSize int32
+	MaxSamples     int32
+	MaxAnisotropy  float32
+	Extensions     []string
+
+	// CompileError (if not nil) returns the info log of the compilation of a shader with the source
+	// or "" if the shader compiles
+	CompileError func(source string) string
+
+	lastName uint32 // the names of all objects are different
+}
+
+// MakeFakeGL returns a pointer to a new FakeGL with 800x600 viewport
+func MakeFakeGL() *FakeGL {
+	return &FakeGL{
+		Buffers:        make(map[uint32]*FakeBuffer),
+		VertexArrays:   make(map[uint32]*FakeVertexArray),
+		Shaders:        make(map[uint32]*FakeShader),
+		Programs:       make(map[uint32]*FakeProgram),
+		Textures:       make(map[uint32]*FakeTexture),
+		Framebuffers:   make(map[uint32]bool),
+		Renderbuffers:  make(map[uint32]bool),
+		Bindings:       make(map[uint32]uint32),
+		Capabilities:   make(map[uint32]bool),
+		Viewport4:      [4]int32{0, 0, 800, 600},
+		PixelStore:     map[uint32]int32{gl.PACK_ALIGNMENT: 4, gl.UNPACK_ALIGNMENT: 4},
+		MaxTextureSize: 4096,
+		MaxSamples:     4,
+		MaxAnisotropy:  16,
+	}
+}
+
+// LiveObjects returns the number of the objects of fake, which have not been deleted
+func (fake *FakeGL) LiveObjects() int {
+	return len(fake.Buffers) + len(fake.VertexArrays) + len(fake.Shaders) + len(fake.Programs) +
+		len(fake.Textures) + len(fake.Framebuffers) + len(fake.Renderbuffers)
+}
+
+// CallCount returns the number of the calls of the function name
+func (fake *FakeGL) CallCount(name string) int {
+	count := 0
+	for _, call := range fake.Calls {
+		if call == name {
+			count++
+		}
+	}
+	return count
+}
+
+// UniformValue returns the value of the uniform name of the program (nil if it has not been set)
+func (fake *FakeGL) UniformValue(program uint32, name string) []float32 {
+	p, ok := fake.Programs[program]
+	if !ok {
+		return nil
+	}
+	location, ok := p.Uniforms[name]
+	if !ok {
+		return nil
+	}
+	return p.Values[location]
+}
+
+func (fake *FakeGL) call(name string) {
+	fake.Calls = append(fake.Calls, name)
+}
+
+func (fake *FakeGL) newName() uint32 {
+	fake.lastName++
+	return fake.lastName
+}
+
+// gen generates n names stored at names and calls add for each of them
+func (fake *FakeGL) gen(n int32, names *uint32, add func(name uint32)) {
+	generated := unsafe.Slice(names, n)
+	for i := range generated {
+		generated[i] = fake.newName()
+		add(generated[i])
+	}
+}
+
+// unbind removes the bindings of the object name
+func (fake *FakeGL) unbind(name uint32, targets ...uint32) {
+	for _, target := range targets {
+		if fake.Bindings[target] == name {
+			delete(fake.Bindings, target)
+		}
+	}
+}
+
+// setUniform sets the value of the uniform at location of the program in use
+func (fake *FakeGL) setUniform(location int32, values []float32) {
+	if p, ok := fake.Programs[fake.Program]; ok && location >= 0 {
+		p.Values[location] = append([]float32(nil), values...)
+	}
+}
+
+// boundVertexArray returns the bound vertex array (or nil)
+func (fake *FakeGL) boundVertexArray() *FakeVertexArray {
+	return fake.VertexArrays[fake.VertexArray]
+}
+
+// boundTexture returns the texture bound to TEXTURE_2D (or nil)
+func (fake *FakeGL) boundTexture() *FakeTexture {
+	return fake.Textures[fake.Bindings[gl.TEXTURE_2D]]
+}
+
+func (fake *FakeGL) ActiveTexture(texture uint32) {
+	fake.call("ActiveTexture")
+	fake.ActiveTexUnit = texture
+}
+
+func (fake *FakeGL) AttachShader(program uint32, shader uint32) {
+	fake.call("AttachShader")
+	if p, ok := fake.Programs[program]; ok {
+		p.Shaders = append(p.Shaders, shader)
+	}
+}
+
+func (fake *FakeGL) BindBuffer(target uint32, buffer uint32) {
+	fake.call("BindBuffer")
+	fake.Bindings[target] = buffer
+}
+
+func (fake *FakeGL) BindFragDataLocation(program uint32, color uint32, name *uint8) {
+	fake.call("BindFragDataLocation")
+}
+
+func (fake *FakeGL) BindFramebuffer(target uint32, framebuffer uint32) {
+	fake.call("BindFramebuffer")
+	if target == gl.FRAMEBUFFER {
+		fake.Bindings[gl.READ_FRAMEBUFFER] = framebuffer
+		fake.Bindings[gl.DRAW_FRAMEBUFFER] = framebuffer
+		return
+	}
+	fake.Bindings[target] = framebuffer
+}
+
+func (fake *FakeGL) BindRenderbuffer(target uint32, renderbuffer uint32) {
+	fake.call("BindRenderbuffer")
+	fake.Bindings[target] = renderbuffer
+}
+
+func (fake *FakeGL) BindTexture(target uint32, texture uint32) {
+	fake.call("BindTexture")
+	fake.Bindings[target] = texture
+}
+
+func (fake *FakeGL) BindVertexArray(array uint32) {
+	fake.call("BindVertexArray")
+	fake.VertexArray = array
+}
+
+func (fake *FakeGL) BlitFramebuffer(srcX0, srcY0, srcX1, srcY1, dstX0, dstY0, dstX1, dstY1 int32, mask uint32, filter uint32) {
+	fake.call("BlitFramebuffer")
+}
+
+func (fake *FakeGL) BufferData(target uint32, size int, data unsafe.Pointer, usage uint32) {
+	fake.call("BufferData")
+	b, ok := fake.Buffers[fake.Bindings[target]]
+	if !ok {
+		return
+	}
+	b.Data = make([]byte, size)
+	if data != nil {
+		copy(b.Data, unsafe.Slice((*byte)(data), size))
+	}
+	b.Usage = usage
+}
+
+func (fake *FakeGL) BufferSubData(target uint32, offset int, size int, data unsafe.Pointer) {
+	fake.call("BufferSubData")
+	b, ok := fake.Buffers[fake.Bindings[target]]
+	if !ok || data == nil || offset < 0 || offset+size > len(b.Data) {
+		return
+	}
+	copy(b.Data[offset:], unsafe.Slice((*byte)(data), size))
+}
+
+func (fake *FakeGL) CheckFramebufferStatus(target uint32) uint32 {
+	fake.call("CheckFramebufferStatus")
+	return gl.FRAMEBUFFER_COMPLETE
+}
+
+func (fake *FakeGL) Clear(mask uint32) {
+	fake.call("Clear")
+}
+
+func (fake *FakeGL) ClearColor(red float32, green float32, blue float32, alpha float32) {
+	fake.call("ClearColor")
+	fake.ClearColor4 = [4]float32{red, green, blue, alpha}
+}
+
+func (fake *FakeGL) CompileShader(shader uint32) {
+	fake.call("CompileShader")
+	if s, ok := fake.Shaders[shader]; ok {
+		s.Log = ""
+		if fake.CompileError != nil {
+			s.Log = fake.CompileError(s.Source)
+		}
+		s.Compiled = s.Log == ""
+	}
+}
+
+func (fake *FakeGL) CopyTexSubImage2D(target uint32, level int32, xoffset int32, yoffset int32, x int32, y int32, width int32, height int32) {
+	fake.call("CopyTexSubImage2D")
+}
+
+func (fake *FakeGL) CreateProgram() uint32 {
+	fake.call("CreateProgram")
+	name := fake.newName()
+	fake.Programs[name] = &FakeProgram{Attributes: make(map[string]int32), Uniforms: make(map[string]int32), Values: make(map[int32][]float32)}
+	return name
+}
+
+func (fake *FakeGL) CreateShader(xtype uint32) uint32 {
+	fake.call("CreateShader")
+	name := fake.newName()
+	fake.Shaders[name] = &FakeShader{Type: xtype}
+	return name
+}
+
+func (fake *FakeGL) DeleteBuffers(n int32, buffers *uint32) {
+	fake.call("DeleteBuffers")
+	for _, name := range unsafe.Slice(buffers, n) {
+		delete(fake.Buffers, name)
+		fake.unbind(name, gl.ARRAY_BUFFER, gl.ELEMENT_ARRAY_BUFFER, gl.COPY_READ_BUFFER, gl.COPY_WRITE_BUFFER)
+	}
+}
+
+func (fake *FakeGL) DeleteFramebuffers(n int32, framebuffers *uint32) {
+	fake.call("DeleteFramebuffers")
+	for _, name := range unsafe.Slice(framebuffers, n) {
+		delete(fake.Framebuffers, name)
+		fake.unbind(name, gl.READ_FRAMEBUFFER, gl.DRAW_FRAMEBUFFER)
+	}
+}
+
+func (fake *FakeGL) DeleteProgram(program uint32) {
+	fake.call("DeleteProgram")
+	delete(fake.Programs, program)
+}
+
+func (fake *FakeGL) DeleteRenderbuffers(n int32, renderbuffers *uint32) {
+	fake.call("DeleteRenderbuffers")
+	for _, name := range unsafe.Slice(renderbuffers, n) {
+		delete(fake.Renderbuffers, name)
+		fake.unbind(name, gl.RENDERBUFFER)
+	}
+}
+
+func (fake *FakeGL) DeleteShader(shader uint32) {
+	fake.call("DeleteShader")
+	delete(fake.Shaders, shader)
+}
+
+func (fake *FakeGL) DeleteTextures(n int32, textures *uint32) {
+	fake.call("DeleteTextures")
+	for _, name := range unsafe.Slice(textures, n) {
+		delete(fake.Textures, name)
+		fake.unbind(name, gl.TEXTURE_2D)
+	}
+}
+
+func (fake *FakeGL) DeleteVertexArrays(n int32, arrays *uint32) {
+	fake.call("DeleteVertexArrays")
+	for _, name := range unsafe.Slice(arrays, n) {
+		delete(fake.VertexArrays, name)
+		if fake.VertexArray == name {
+			fake.VertexArray = 0
+		}
+	}
+}
+
+func (fake *FakeGL) Disable(cap uint32) {
+	fake.call("Disable")
+	delete(fake.Capabilities, cap)
+}
+
+func (fake *FakeGL) DisableVertexAttribArray(index uint32) {
+	fake.call("DisableVertexAttribArray")
+	if a, ok := fake.boundVertexArray().attribute(index); ok {
+		a.Enabled = false
+	}
+}
+
+func (fake *FakeGL) DrawArrays(mode uint32, first int32, count int32) {
+	fake.call("DrawArrays")
+	fake.Draws = append(fake.Draws, FakeDraw{Mode: mode, First: first, Count: count, Program: fake.Program,
+		VertexArray: fake.VertexArray, Texture: fake.Bindings[gl.TEXTURE_2D]})
+}
+
+func (fake *FakeGL) DrawArraysInstanced(mode uint32, first int32, count int32, instancecount int32) {
+	fake.call("DrawArraysInstanced")
+	fake.Draws = append(fake.Draws, FakeDraw{Mode: mode, First: first, Count: count, Instances: instancecount,
+		Program: fake.Program, VertexArray: fake.VertexArray, Texture: fake.Bindings[gl.TEXTURE_2D]})
+}
+
+func (fake *FakeGL) Enable(cap uint32) {
+	fake.call("Enable")
+	fake.Capabilities[cap] = true
+}
+
+func (fake *FakeGL) EnableVertexAttribArray(index uint32) {
+	fake.call("EnableVertexAttribArray")
+	if a, ok := fake.boundVertexArray().attribute(index); ok {
+		a.Enabled = true
+	}
+}
+
+func (fake *FakeGL) FramebufferRenderbuffer(target uint32, attachment uint32, renderbuffertarget uint32, renderbuffer uint32) {
+	fake.call("FramebufferRenderbuffer")
+}
+
+func (fake *FakeGL) FramebufferTexture2D(target uint32, attachment uint32, textarget uint32, texture uint32, level int32) {
+	fake.call("FramebufferTexture2D")
+}
+
+func (fake *FakeGL) GenBuffers(n int32, buffers *uint32) {
+	fake.call("GenBuffers")
+	fake.gen(n, buffers, func(name uint32) { fake.Buffers[name] = &FakeBuffer{} })
+}
+
+func (fake *FakeGL) GenFramebuffers(n int32, framebuffers *uint32) {
+	fake.call("GenFramebuffers")
+	fake.gen(n, framebuffers, func(name uint32) { fake.Framebuffers[name] = true })
+}
+
+func (fake *FakeGL) GenRenderbuffers(n int32, renderbuffers *uint32) {
+	fake.call("GenRenderbuffers")
+	fake.gen(n, renderbuffers, func(name uint32) { fake.Renderbuffers[name] = true })
+}
+
+func (fake *FakeGL) GenTextures(n int32, textures *uint32) {
+	fake.call("GenTextures")
+	fake.gen(n, textures, func(name uint32) { fake.Textures[name] = &FakeTexture{Parameters: make(map[uint32]float32)} })
+}
+
+func (fake *FakeGL) GenVertexArrays(n int32, arrays *uint32) {
+	fake.call("GenVertexArrays")
+	fake.gen(n, arrays, func(name uint32) {
+		fake.VertexArrays[name] = &FakeVertexArray{Attributes: make(map[uint32]*FakeAttribute)}
+	})
+}
+
+func (fake *FakeGL) GenerateMipmap(target uint32) {
+	fake.call("GenerateMipmap")
+	if t := fake.boundTexture(); t != nil {
+		t.Mipmaps = true
+	}
+}
+
+func (fake *FakeGL) GetAttribLocation(program uint32, name *uint8) int32 {
+	fake.call("GetAttribLocation")
+	p, ok := fake.Programs[program]
+	if !ok {
+		return -1
+	}
+	return fakeLocation(p.Attributes, gl.GoStr(name))
+}
+
+func (fake *FakeGL) GetFloatv(pname uint32, data *float32) {
+	fake.call("GetFloatv")
+	switch pname {
+	case gl.COLOR_CLEAR_VALUE:
+		copy(unsafe.Slice(data, 4), fake.ClearColor4[:])
+	case maxTextureMaxAnisotropy:
+		*data = fake.MaxAnisotropy
+	}
+}
+
+func (fake *FakeGL) GetIntegerv(pname uint32, data *int32) {
+	fake.call("GetIntegerv")
+	switch pname {
+	case gl.VIEWPORT:
+		copy(unsafe.Slice(data, 4), fake.Viewport4[:])
+	case gl.DRAW_FRAMEBUFFER_BINDING: // the same as FRAMEBUFFER_BINDING
+		*data = int32(fake.Bindings[gl.DRAW_FRAMEBUFFER])
+	case gl.READ_FRAMEBUFFER_BINDING:
+		*data = int32(fake.Bindings[gl.READ_FRAMEBUFFER])
+	case gl.RENDERBUFFER_BINDING:
+		*data = int32(fake.Bindings[gl.RENDERBUFFER])
+	case gl.TEXTURE_BINDING_2D:
+		*data = int32(fake.Bindings[gl.TEXTURE_2D])
+	case gl.ARRAY_BUFFER_BINDING:
+		*data = int32(fake.Bindings[gl.ARRAY_BUFFER])
+	case gl.VERTEX_ARRAY_BINDING:
+		*data = int32(fake.VertexArray)
+	case gl.CURRENT_PROGRAM:
+		*data = int32(fake.Program)
+	case gl.MAX_TEXTURE_SIZE:
+		*data = fake.MaxTextureSize
+	case gl.MAX_SAMPLES:
+		*data = fake.MaxSamples
+	case gl.NUM_EXTENSIONS:
+		*data = int32(len(fake.Extensions))
+	case gl.PACK_ALIGNMENT, gl.UNPACK_ALIGNMENT:
+		*data = fake.PixelStore[pname]
+	}
+}
+
+func (fake *FakeGL) GetProgramInfoLog(program uint32, bufSize int32, length *int32, infoLog *uint8) {
+	fake.call("GetProgramInfoLog")
+}
+
+func (fake *FakeGL) GetProgramiv(program uint32, pname uint32, params *int32) {
+	fake.call("GetProgramiv")
+	switch pname {
+	case gl.LINK_STATUS:
+		*params = gl.FALSE
+		if p, ok := fake.Programs[program]; ok && p.Linked {
+			*params = gl.TRUE
+		}
+	case gl.INFO_LOG_LENGTH:
+		*params = 0
+	}
+}
+
+func (fake *FakeGL) GetShaderInfoLog(shader uint32, bufSize int32, length *int32, infoLog *uint8) {
+	fake.call("GetShaderInfoLog")
+	s, ok := fake.Shaders[shader]
+	if !ok || bufSize <= 0 {
+		return
+	}
+	buf := unsafe.Slice(infoLog, bufSize)
+	n := copy(buf[:bufSize-1], s.Log)
+	buf[n] = 0 // terminating zero
+	if length != nil {
+		*length = int32(n)
+	}
+}
+
+func (fake *FakeGL) GetShaderiv(shader uint32, pname uint32, params *int32) {
+	fake.call("GetShaderiv")
+	switch pname {
+	case gl.COMPILE_STATUS:
+		*params = gl.FALSE
+		if s, ok := fake.Shaders[shader]; ok && s.Compiled {
+			*params = gl.TRUE
+		}
+	case gl.INFO_LOG_LENGTH:
+		*params = 0
+		if s, ok := fake.Shaders[shader]; ok && s.Log != "" {
+			*params = int32(len(s.Log) + 1) // with the terminating zero
+		}
+	}
+}
+
+func (fake *FakeGL) GetStringi(name uint32, index uint32) *uint8 {
+	fake.call("GetStringi")
+	if name != gl.EXTENSIONS || int(index) >= len(fake.Extensions) {
+		return nil
+	}
+	return gl.Str(fake.Extensions[index] + "\x00")
+}
+
+func (fake *FakeGL) GetTexImage(target uint32, level int32, format uint32, xtype uint32, pixels unsafe.Pointer) {
+	fake.call("GetTexImage")
+}
+
+func (fake *FakeGL) GetTexLevelParameteriv(target uint32, level int32, pname uint32, params *int32) {
+	fake.call("GetTexLevelParameteriv")
+	t := fake.boundTexture()
+	if t == nil {
+		*params = 0
+		return
+	}
+	switch pname {
+	case gl.TEXTURE_WIDTH:
+		*params = t.Width
+	case gl.TEXTURE_HEIGHT:
+		*params = t.Height
+	}
+}
+
+func (fake *FakeGL) GetUniformLocation(program uint32, name *uint8) int32 {
+	fake.call("GetUniformLocation")
+	p, ok := fake.Programs[program]
+	if !ok {
+		return -1
+	}
+	return fakeLocation(p.Uniforms, gl.GoStr(name))
+}
+
+func (fake *FakeGL) IsEnabled(cap uint32) bool {
+	fake.call("IsEnabled")
+	return fake.Capabilities[cap]
+}
+
+func (fake *FakeGL) LinkProgram(program uint32) {
+	fake.call("LinkProgram")
+	if p, ok := fake.Programs[program]; ok {
+		p.Linked = true
+	}
+}
+
+func (fake *FakeGL) PixelStorei(pname uint32, param int32) {
+	fake.call("PixelStorei")
+	fake.PixelStore[pname] = param
+}
+
+func (fake *FakeGL) ReadPixels(x int32, y int32, width int32, height int32, format uint32, xtype uint32, pixels unsafe.Pointer) {
+	fake.call("ReadPixels")
+}
+
+func (fake *FakeGL) RenderbufferStorage(target uint32, internalformat uint32, width int32, height int32) {
+	fake.call("RenderbufferStorage")
+}
+
+func (fake *FakeGL) RenderbufferStorageMultisample(target uint32, samples int32, internalformat uint32, width int32, height int32) {
+	fake.call("RenderbufferStorageMultisample")
+}
+
+func (fake *FakeGL) ShaderSource(shader uint32, count int32, xstring **uint8, length *int32) {
+	fake.call("ShaderSource")
+	if s, ok := fake.Shaders[shader]; ok {
+		s.Source = ""
+		for _, str := range unsafe.Slice(xstring, count) {
+			s.Source += gl.GoStr(str)
+		}
+	}
+}
+
+func (fake *FakeGL) TexImage2D(target uint32, level int32, internalformat int32, width int32, height int32, border int32, format uint32, xtype uint32, pixels unsafe.Pointer) {
+	fake.call("TexImage2D")
+	if t := fake.boundTexture(); t != nil && level == 0 {
+		t.Width, t.Height = width, height
+	}
+}
+
+func (fake *FakeGL) TexParameterf(target uint32, pname uint32, param float32) {
+	fake.call("TexParameterf")
+	if t := fake.boundTexture(); t != nil {
+		t.Parameters[pname] = param
+	}
+}
+
+func (fake *FakeGL) TexParameteri(target uint32, pname uint32, param int32) {
+	fake.call("TexParameteri")
+	if t := fake.boundTexture(); t != nil {
+		t.Parameters[pname] = float32(param)
+	}
+}
+
+func (fake *FakeGL) Uniform1f(location int32, v0 float32) {
+	fake.call("Uniform1f")
+	fake.setUniform(location, []float32{v0})
+}
+
+func (fake *FakeGL) Uniform1i(location int32, v0 int32) {
+	fake.call("Uniform1i")
+	fake.setUniform(location, []float32{float32(v0)})
+}
+
+func (fake *FakeGL) Uniform3fv(location int32, count int32, value *float32) {
+	fake.call("Uniform3fv")
+	fake.setUniform(location, unsafe.Slice(value, 3*count))
+}
+
+func (fake *FakeGL) UniformMatrix4fv(location int32, count int32, transpose bool, value *float32) {
+	fake.call("UniformMatrix4fv")
+	fake.setUniform(location, unsafe.Slice(value, 16*count))
+}
+
+func (fake *FakeGL) UseProgram(program uint32) {
+	fake.call("UseProgram")
+	fake.Program = program
+}
+
+func (fake *FakeGL) VertexAttribDivisor(index uint32, divisor uint32) {
+	fake.call("VertexAttribDivisor")
+	if a, ok := fake.boundVertexArray().attribute(index); ok {
+		a.Divisor = divisor
+	}
+}
+
+func (fake *FakeGL) VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, pointer unsafe.Pointer) {
+	fake.call("VertexAttribPointer")
+	if a, ok := fake.boundVertexArray().attribute(index); ok {
+		a.Buffer = fake.Bindings[gl.ARRAY_BUFFER]
+		a.Size, a.Type, a.Normalized, a.Stride, a.Offset = size, xtype, normalized, stride, uintptr(pointer)
+	}
+}
+
+func (fake *FakeGL) Viewport(x int32, y int32, width int32, height int32) {
+	fake.call("Viewport")
+	fake.Viewport4 = [4]int32{x, y, width, height}
+}
+
+// attribute returns the attribute index of va (created if needed) or false if va is nil
+func (va *FakeVertexArray) attribute(index uint32) (*FakeAttribute, bool) {
+	if va == nil {
+		return nil, false
+	}
+	a, ok := va.Attributes[index]
+	if !ok {
+		a = &FakeAttribute{}
+		va.Attributes[index] = a
+	}
+	return a, true
+}
+
+// fakeLocation returns the location of name in locations (assigned if needed)
+func fakeLocation(locations map[string]int32, name string) int32 {
+	location, ok := locations[name]
+	if !ok {
+		location = int32(len(locations))
+		locations[name] = location
+	}
+	return location
+}
@@ -0,0 +1,148 @@
+package glmki3d
+
+import (
+	"errors"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"image"
+	"strconv"
+)
+
+/* offscreen rendering to images */
+
+// makeRenderFramebuffer either returns a new framebuffer object with the color (and depth if depth is true)
+// renderbuffers of the size width x height with samples samples (no multisampling if samples <= 1) or an error.
+// The framebuffer is left bound to FRAMEBUFFER.
+func makeRenderFramebuffer(width, height, samples int, depth bool) (framebuffer uint32, renderbuffers []uint32, err error) {
+	glb.GenFramebuffers(1, &framebuffer)
+	glb.BindFramebuffer(gl.FRAMEBUFFER, framebuffer)
+
+	attach := func(format, attachment uint32) {
+		var renderbuffer uint32
+		glb.GenRenderbuffers(1, &renderbuffer)
+		glb.BindRenderbuffer(gl.RENDERBUFFER, renderbuffer)
+		if samples > 1 {
+			glb.RenderbufferStorageMultisample(gl.RENDERBUFFER, int32(samples), format, int32(width), int32(height))
+		} else {
+			glb.RenderbufferStorage(gl.RENDERBUFFER, format, int32(width), int32(height))
+		}
+		glb.FramebufferRenderbuffer(gl.FRAMEBUFFER, attachment, gl.RENDERBUFFER, renderbuffer)
+		renderbuffers = append(renderbuffers, renderbuffer)
+	}
+	attach(gl.RGBA8, gl.COLOR_ATTACHMENT0)
+	if depth {
+		attach(gl.DEPTH_COMPONENT24, gl.DEPTH_ATTACHMENT)
+	}
+
+	if status := glb.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		deleteRenderFramebuffer(framebuffer, renderbuffers)
+		return 0, nil, errors.New("framebuffer is not complete (status " + strconv.Itoa(int(status)) + ")")
+	}
+	return framebuffer, renderbuffers, nil
+}
+
+// deleteRenderFramebuffer deletes the framebuffer and its renderbuffers made with makeRenderFramebuffer
+func deleteRenderFramebuffer(framebuffer uint32, renderbuffers []uint32) {
+	glb.DeleteFramebuffers(1, &framebuffer)
+	for i := range renderbuffers {
+		glb.DeleteRenderbuffers(1, &renderbuffers[i])
+	}
+}
+
+// RenderImage either returns the image of the stage of ds drawn offscreen in the size width x height or an error.
+// The stage is drawn with the uniforms of ds.UniPtr, except for the projection which is computed
+// from ds.Mki3dPtr.Projection for the size of the image (the uniforms are restored afterwards).
+// If samples > 1, then multisampling with samples samples (limited by GL_MAX_SAMPLES) is used.
+// The bound framebuffers, the viewport, the clear color, the depth test and the pack alignment are restored.
+func RenderImage(ds *DataShader, width, height, samples int) (*image.RGBA, error) {
+	if ds == nil {
+		return nil, errors.New("ds == nil // type *DataShader")
+	}
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("width <= 0 || height <= 0")
+	}
+
+	// remember the state changed by the rendering
+	var readFBO, drawFBO, renderbuffer int32
+	glb.GetIntegerv(gl.READ_FRAMEBUFFER_BINDING, &readFBO)
+	glb.GetIntegerv(gl.DRAW_FRAMEBUFFER_BINDING, &drawFBO)
+	glb.GetIntegerv(gl.RENDERBUFFER_BINDING, &renderbuffer)
+	var viewport [4]int32
+	glb.GetIntegerv(gl.VIEWPORT, &viewport[0])
+	var clearColor [4]float32
+	glb.GetFloatv(gl.COLOR_CLEAR_VALUE, &clearColor[0])
+	depthTest := glb.IsEnabled(gl.DEPTH_TEST)
+	var packAlignment int32
+	glb.GetIntegerv(gl.PACK_ALIGNMENT, &packAlignment)
+	defer func() {
+		glb.BindFramebuffer(gl.READ_FRAMEBUFFER, uint32(readFBO))
+		glb.BindFramebuffer(gl.DRAW_FRAMEBUFFER, uint32(drawFBO))
+		glb.BindRenderbuffer(gl.RENDERBUFFER, uint32(renderbuffer))
+		glb.Viewport(viewport[0], viewport[1], viewport[2], viewport[3])
+		glb.ClearColor(clearColor[0], clearColor[1], clearColor[2], clearColor[3])
+		if !depthTest {
+			glb.Disable(gl.DEPTH_TEST)
+		}
+		glb.PixelStorei(gl.PACK_ALIGNMENT, packAlignment)
+	}()
+
+	if samples > 1 {
+		var maxSamples int32
+		glb.GetIntegerv(gl.MAX_SAMPLES, &maxSamples)
+		if samples > int(maxSamples) {
+			samples = int(maxSamples)
+		}
+	}
+
+	// framebuffer to which the stage is drawn
+	drawFramebuffer, drawRenderbuffers, err := makeRenderFramebuffer(width, height, samples, true)
+	if err != nil {
+		return nil, err
+	}
+	defer deleteRenderFramebuffer(drawFramebuffer, drawRenderbuffers)
+
+	// framebuffer from which the pixels are read (multisampled framebuffer has to be resolved to it)
+	readFramebuffer := drawFramebuffer
+	if samples > 1 {
+		var readRenderbuffers []uint32
+		readFramebuffer, readRenderbuffers, err = makeRenderFramebuffer(width, height, 0, false)
+		if err != nil {
+			return nil, err
+		}
+		defer deleteRenderFramebuffer(readFramebuffer, readRenderbuffers)
+	}
+
+	glb.BindFramebuffer(gl.FRAMEBUFFER, drawFramebuffer)
+	glb.Viewport(0, 0, int32(width), int32(height))
+	glb.Enable(gl.DEPTH_TEST)
+	ds.SetBackgroundColor()
+	glb.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+
+	projection := ds.UniPtr.ProjectionUni
+	ds.UniPtr.SetProjectionFromMki3d(ds.Mki3dPtr, width, height)
+	ds.DrawStage()
+	ds.UniPtr.ProjectionUni = projection
+	ds.InitStage() // restore the uniforms in the shaders
+
+	if samples > 1 {
+		glb.BindFramebuffer(gl.READ_FRAMEBUFFER, drawFramebuffer)
+		glb.BindFramebuffer(gl.DRAW_FRAMEBUFFER, readFramebuffer)
+		glb.BlitFramebuffer(0, 0, int32(width), int32(height), 0, 0, int32(width), int32(height), gl.COLOR_BUFFER_BIT, gl.NEAREST)
+	}
+
+	glb.BindFramebuffer(gl.READ_FRAMEBUFFER, readFramebuffer)
+	pixels := make([]uint8, 4*width*height)
+	glb.PixelStorei(gl.PACK_ALIGNMENT, 4) // rows of RGBA pixels are always aligned to 4 bytes
+	glb.ReadPixels(0, 0, int32(width), int32(height), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(&pixels[0]))
+
+	// the rows of GL framebuffer are from the bottom to the top
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	rowSize := 4 * width
+	for y := 0; y < height; y++ {
+		copy(img.Pix[y*img.Stride:y*img.Stride+rowSize], pixels[(height-1-y)*rowSize:(height-y)*rowSize])
+	}
+	// the shaders write the shading also to the alpha, which is ignored on the screen
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
+	}
+	return img, nil
+}
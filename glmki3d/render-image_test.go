@@ -0,0 +1,45 @@
+package glmki3d
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"testing"
+)
+
+func TestRenderImageRestoresState(t *testing.T) {
+	fake := useFakeGL(t)
+	ctx, ds := makeTestDataShader(t)
+	defer ctx.Close()
+
+	fake.PixelStorei(gl.PACK_ALIGNMENT, 2)
+	fake.Viewport(1, 2, 300, 200)
+	fake.ClearColor(0.5, 0.25, 0, 1)
+	framebuffers, renderbuffers := len(fake.Framebuffers), len(fake.Renderbuffers)
+
+	for _, samples := range []int{0, 4} {
+		img, err := RenderImage(ds, 16, 8, samples)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size := img.Bounds().Size(); size.X != 16 || size.Y != 8 {
+			t.Errorf("samples %d: image size %v, want 16x8", samples, size)
+		}
+		if fake.PixelStore[gl.PACK_ALIGNMENT] != 2 {
+			t.Errorf("samples %d: pack alignment %d, want 2", samples, fake.PixelStore[gl.PACK_ALIGNMENT])
+		}
+		if fake.Viewport4 != [4]int32{1, 2, 300, 200} {
+			t.Errorf("samples %d: viewport %v not restored", samples, fake.Viewport4)
+		}
+		if fake.ClearColor4 != [4]float32{0.5, 0.25, 0, 1} {
+			t.Errorf("samples %d: clear color %v not restored", samples, fake.ClearColor4)
+		}
+		if fake.Capabilities[gl.DEPTH_TEST] {
+			t.Errorf("samples %d: depth test left enabled", samples)
+		}
+		if fake.Bindings[gl.READ_FRAMEBUFFER] != 0 || fake.Bindings[gl.DRAW_FRAMEBUFFER] != 0 {
+			t.Errorf("samples %d: framebuffers %d, %d left bound", samples, fake.Bindings[gl.READ_FRAMEBUFFER], fake.Bindings[gl.DRAW_FRAMEBUFFER])
+		}
+		if len(fake.Framebuffers) != framebuffers || len(fake.Renderbuffers) != renderbuffers {
+			t.Errorf("samples %d: framebuffers or renderbuffers not deleted", samples)
+		}
+	}
+}
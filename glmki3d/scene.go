@@ -0,0 +1,209 @@
+package glmki3d
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/mki1967/go-mki3d/mki3d"
+	"math"
+)
+
+/* scene graph of mki3d models with hierarchical transforms */
+
+// SceneNode is a node of a scene graph.
+// Each node has a local transform (relative to the parent) and may refer to a DataShader shared with other nodes.
+type SceneNode struct {
+	Label         string        // optional label of the node
+	Local         mgl32.Mat4    // transform relative to the parent node
+	World         mgl32.Mat4    // world transform computed by UpdateWorld
+	Visible       bool          // invisible nodes are not drawn (together with their children)
+	DataShaderPtr *DataShader   // the model of the node (may be nil)
+	Children      []*SceneNode  // child nodes
+	LocalBox      mki3d.BoxType // bounding box of the model of the node (in model coordinates)
+	WorldBox      mki3d.BoxType // bounding box of the node with its children (in world coordinates) computed by UpdateWorld
+	Parent        *SceneNode    // parent node (nil for the root)
+}
+
+// MakeSceneNode returns a pointer to a new visible node with identity local transform and the model dsPtr (may be nil).
+func MakeSceneNode(dsPtr *DataShader) *SceneNode {
+	node := SceneNode{Local: mgl32.Ident4(), World: mgl32.Ident4(), Visible: true, DataShaderPtr: dsPtr}
+	node.UpdateLocalBox()
+	return &node
+}
+
+// UpdateLocalBox recomputes node.LocalBox from the data of node.DataShaderPtr.
+// Call it after the model of the node has been changed.
+func (node *SceneNode) UpdateLocalBox() {
+	if node.DataShaderPtr == nil || node.DataShaderPtr.Mki3dPtr == nil {
+		node.LocalBox = mki3d.MakeEmptyBox()
+		return
+	}
+	node.LocalBox = node.DataShaderPtr.Mki3dPtr.BoundingBox()
+}
+
+// AddChild appends child to the children of node
+func (node *SceneNode) AddChild(child *SceneNode) {
+	child.Parent = node
+	node.Children = append(node.Children, child)
+}
+
+// RemoveChild removes child from the children of node.
+// It returns false if child is not a child of node.
+func (node *SceneNode) RemoveChild(child *SceneNode) bool {
+	for i, c := range node.Children {
+		if c == child {
+			node.Children = append(node.Children[:i], node.Children[i+1:]...)
+			child.Parent = nil
+			return true
+		}
+	}
+	return false
+}
+
+// transformBox returns the world-aligned box containing box transformed by m
+func transformBox(box mki3d.BoxType, m mgl32.Mat4) mki3d.BoxType {
+	result := mki3d.MakeEmptyBox()
+	if box.Empty {
+		return result
+	}
+	for i := 0; i < 8; i++ { // corners of the box
+		corner := box.Min
+		for axis := 0; axis < 3; axis++ {
+			if i&(1<<uint(axis)) != 0 {
+				corner[axis] = box.Max[axis]
+			}
+		}
+		p := m.Mul4x1(mgl32.Vec3(corner).Vec4(1))
+		result.AddPoint(mki3d.Vector3dType{p[0], p[1], p[2]})
+	}
+	return result
+}
+
+// UpdateWorld computes World transforms and WorldBox bounding boxes of node and all its descendants.
+// parentWorld is the world transform of the parent (use mgl32.Ident4() for the root).
+func (node *SceneNode) UpdateWorld(parentWorld mgl32.Mat4) {
+	node.World = parentWorld.Mul4(node.Local)
+	node.WorldBox = transformBox(node.LocalBox, node.World)
+	for _, child := range node.Children {
+		child.UpdateWorld(node.World)
+		node.WorldBox.AddBox(child.WorldBox)
+	}
+}
+
+// Frustum contains six planes (a,b,c,d) of a view frustum.
+// A point (x,y,z) is inside the frustum if a*x+b*y+c*z+d >= 0 for all planes.
+type Frustum [6]mgl32.Vec4
+
+// MakeFrustum returns the frustum of the projection and view matrices (in world coordinates).
+func MakeFrustum(projection, view mgl32.Mat4) Frustum {
+	m := projection.Mul4(view)
+	var f Frustum
+	for i := 0; i < 3; i++ {
+		f[2*i] = m.Row(3).Add(m.Row(i))
+		f[2*i+1] = m.Row(3).Sub(m.Row(i))
+	}
+	return f
+}
+
+// MakeFrustumFromUni returns the frustum of the projection and view matrices of glUni.
+func MakeFrustumFromUni(glUni *GLUni) Frustum {
+	return MakeFrustum(glUni.ProjectionUni, glUni.ViewUni)
+}
+
+// IntersectsBox returns false if the box is surely outside the frustum f.
+func (f *Frustum) IntersectsBox(box mki3d.BoxType) bool {
+	if box.Empty {
+		return false
+	}
+	for _, plane := range f {
+		// the corner of the box farthest in the direction of the plane normal
+		var p mgl32.Vec4
+		for axis := 0; axis < 3; axis++ {
+			if plane[axis] >= 0 {
+				p[axis] = box.Max[axis]
+			} else {
+				p[axis] = box.Min[axis]
+			}
+		}
+		p[3] = 1
+		if plane.Dot(p) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Draw draws the models of the visible nodes of the subtree of node with their World transforms
+// (computed previously by UpdateWorld). If frustum != nil, then the nodes with bounding boxes
+// outside the frustum are skipped. The stage parameters of the shaders (projection, view, light)
+// should be initiated before (e.g. by DrawStage of the stage DataShader).
+func (node *SceneNode) Draw(frustum *Frustum) {
+	if !node.Visible {
+		return
+	}
+	if frustum != nil && !frustum.IntersectsBox(node.WorldBox) {
+		return
+	}
+	if ds := node.DataShaderPtr; ds != nil {
+		savedModel := ds.UniPtr.ModelUni
+		ds.UniPtr.ModelUni = node.World
+		ds.DrawModel()
+		ds.UniPtr.ModelUni = savedModel
+	}
+	for _, child := range node.Children {
+		child.Draw(frustum)
+	}
+}
+
+// rayBoxDistance returns the distance t >= 0 along the ray origin+t*dir to the first intersection with box
+// and true, or false if the ray misses the box.
+func rayBoxDistance(origin, dir mgl32.Vec3, box mki3d.BoxType) (float32, bool) {
+	if box.Empty {
+		return 0, false
+	}
+	tMin, tMax := float32(0), float32(math.MaxFloat32)
+	for axis := 0; axis < 3; axis++ {
+		if dir[axis] == 0 {
+			if origin[axis] < box.Min[axis] || origin[axis] > box.Max[axis] {
+				return 0, false
+			}
+			continue
+		}
+		t1 := (box.Min[axis] - origin[axis]) / dir[axis]
+		t2 := (box.Max[axis] - origin[axis]) / dir[axis]
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		if t1 > tMin {
+			tMin = t1
+		}
+		if t2 < tMax {
+			tMax = t2
+		}
+		if tMin > tMax {
+			return 0, false
+		}
+	}
+	return tMin, true
+}
+
+// Pick returns the visible node with a model in the subtree of node whose world bounding box
+// is the nearest one hit by the ray origin+t*dir (t >= 0), and the distance t.
+// It returns nil if no such node is hit. World boxes should be computed previously by UpdateWorld.
+func (node *SceneNode) Pick(origin, dir mgl32.Vec3) (picked *SceneNode, distance float32) {
+	if !node.Visible {
+		return nil, 0
+	}
+	if _, hit := rayBoxDistance(origin, dir, node.WorldBox); !hit {
+		return nil, 0
+	}
+	if node.DataShaderPtr != nil {
+		if t, hit := rayBoxDistance(origin, dir, transformBox(node.LocalBox, node.World)); hit {
+			picked, distance = node, t
+		}
+	}
+	for _, child := range node.Children {
+		if p, t := child.Pick(origin, dir); p != nil && (picked == nil || t < distance) {
+			picked, distance = p, t
+		}
+	}
+	return picked, distance
+}
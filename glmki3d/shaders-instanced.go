@@ -0,0 +1,136 @@
+package glmki3d
+
+// Instanced variants of the shaders.
+// The model matrix of each instance is taken from the attribute instanceModel
+// (locations 4, 5, 6, 7 - one per column) and it is applied before the uniform model matrix.
+
+// instanceModelAttr is the location of the first column of instanceModel attribute
+const instanceModelAttr = 4
+
+// Vertex shader for drawing instanced triangles
+var vertexShaderTInstanced = `
+#version 330
+
+/* attributes */
+layout (location = 0) in vec3 position;
+layout (location = 1) in vec3 normal;
+layout (location = 2) in vec3 color;
+layout (location = 4) in mat4 instanceModel;
+
+/* uniforms */
+uniform mat4 model;
+uniform mat4 view;
+uniform mat4 projection;
+uniform vec3 light;
+uniform float ambient;
+
+/* output to fragment shader */
+out vec4 vColor;
+out vec3 vWorld;
+
+void main() {
+    mat4 m = model*instanceModel;
+    /* compute shaded color */
+    vec4 modelNormal=m*vec4(normal, 0.0);
+    float shade= abs( dot( normalize(modelNormal.xyz), light ) );
+    vColor= (ambient+(1.0-ambient)*shade)*vec4(color, 1.0);
+    /* compute world and projected position */
+    vec4 worldPosition = m*vec4(position, 1.0);
+    vWorld = worldPosition.xyz;
+    gl_Position = projection*view*worldPosition;
+}
+` + "\x00"
+
+// vertex shader for drawing instanced segments
+var vertexShaderSInstanced = `
+#version 330
+
+/* attributes */
+layout (location = 0) in vec3 position;
+layout (location = 2) in vec3 color;
+layout (location = 4) in mat4 instanceModel;
+
+/* uniforms */
+uniform mat4 model;
+uniform mat4 view;
+uniform mat4 projection;
+
+/* output to fragment shader */
+out vec4 vColor;
+out vec3 vWorld;
+
+void main() {
+    /* compute shaded color */
+    vColor= vec4(color, 1.0);
+    /* compute world and projected position */
+    vec4 worldPosition = model*instanceModel*vec4(position, 1.0);
+    vWorld = worldPosition.xyz;
+    gl_Position = projection*view*worldPosition;
+}
+` + "\x00"
+
+// vertex shader for drawing instanced textured triangles
+var vertexShaderTexInstanced = `
+#version 330
+/* attributes */
+layout (location = 0) in vec3 position;
+layout (location = 1) in vec3 normal;
+layout (location = 2) in vec2 texAttr;
+layout (location = 4) in mat4 instanceModel;
+/* uniforms */
+uniform mat4 model;
+uniform mat4 view;
+uniform mat4 projection;
+uniform vec3 light;
+uniform float ambient;
+
+/* output to fragment shader */
+out vec3 texUVS; // (u,v,shade)
+out vec3 vWorld;
+void main() {
+    mat4 m = model*instanceModel;
+    /* compute shaded color */
+    vec4 modelNormal=m*vec4(normal, 0.0);
+    float shade= abs( dot( normalize(modelNormal.xyz), light ) ); // diffuse factor
+    texUVS.xy=texAttr;
+    texUVS.z=(ambient+(1.0-ambient)*shade); // shading scaling factor
+    /* compute world and projected position */
+    vec4 worldPosition = m*vec4(position, 1.0);
+    vWorld = worldPosition.xyz;
+    gl_Position = projection*view*worldPosition;
+}
+` + "\x00"
+
+// ShaderInstanced contains the instanced variants of the shaders for segments, triangles and textured triangles.
+type ShaderInstanced struct {
+	SegPtr *ShaderSeg
+	TrPtr  *ShaderTr
+	TexPtr *ShaderTex
+}
+
+// MakeShaderInstanced compiles the instanced shaders and returns them in ShaderInstanced structure
+func MakeShaderInstanced() (shaderPtr *ShaderInstanced, err error) {
+	shaderSeg, err := makeShaderSeg(vertexShaderSInstanced)
+	if err != nil {
+		return nil, err
+	}
+
+	shaderTr, err := makeShaderTr(vertexShaderTInstanced)
+	if err != nil {
+		return nil, err
+	}
+
+	shaderTex, err := makeShaderTex(vertexShaderTexInstanced)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ShaderInstanced{SegPtr: shaderSeg, TrPtr: shaderTr, TexPtr: shaderTex}, nil
+}
+
+// Delete the programs of the instanced shaders in GL, when they are not needed any more
+func (shader *ShaderInstanced) Delete() {
+	glb.DeleteProgram(shader.SegPtr.ProgramId)
+	glb.DeleteProgram(shader.TrPtr.ProgramId)
+	glb.DeleteProgram(shader.TexPtr.ProgramId)
+}
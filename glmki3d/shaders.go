@@ -24,14 +24,17 @@ uniform float ambient;
  
 /* output to fragment shader */
 out vec4 vColor;
+out vec3 vWorld;
 
 void main() {
     /* compute shaded color */
     vec4 modelNormal=model*vec4(normal, 0.0);
     float shade= abs( dot( modelNormal.xyz, light ) ); 
     vColor= (ambient+(1.0-ambient)*shade)*vec4(color, 1.0);
-    /* compute projected position */
-    gl_Position = projection*view*model*vec4(position, 1.0);
+    /* compute world and projected position */
+    vec4 worldPosition = model*vec4(position, 1.0);
+    vWorld = worldPosition.xyz;
+    gl_Position = projection*view*worldPosition;
 }
 ` + "\x00"
 
@@ -50,12 +53,15 @@ uniform mat4 projection;
  
 /* output to fragment shader */
 out vec4 vColor;
+out vec3 vWorld;
 
 void main() {
     /* compute shaded color */
     vColor= vec4(color, 1.0);
-    /* compute projected position */
-    gl_Position = projection*view*model*vec4(position, 1.0);
+    /* compute world and projected position */
+    vec4 worldPosition = model*vec4(position, 1.0);
+    vWorld = worldPosition.xyz;
+    gl_Position = projection*view*worldPosition;
 }
 ` + "\x00"
 
@@ -65,32 +71,40 @@ var fragmentShader = `
 
 /* input from vertex shader */
 in vec4 vColor;
+in vec3 vWorld;
+
+/* uniforms */
+uniform vec3 clipMin;
+uniform vec3 clipMax;
 
 /* fragment color output */
 out vec4 outputColor;
 
 void main() {
+    /* discard fragments outside the clipping box */
+    if( any(lessThan(vWorld, clipMin)) || any(greaterThan(vWorld, clipMax)) ) discard;
     outputColor = vColor ;
 }
 ` + "\x00"
 
 // from https://github.com/go-gl/examples/blob/master/gl41core-cube/cube.go
 func compileShader(source string, shaderType uint32) (uint32, error) {
-	shader := gl.CreateShader(shaderType)
+	shader := glb.CreateShader(shaderType)
 
 	csources, free := gl.Strs(source)
-	gl.ShaderSource(shader, 1, csources, nil)
+	glb.ShaderSource(shader, 1, csources, nil)
 	free()
-	gl.CompileShader(shader)
+	glb.CompileShader(shader)
 
 	var status int32
-	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
+	glb.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
 	if status == gl.FALSE {
 		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+		glb.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
 
 		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
+		glb.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
+		glb.DeleteShader(shader)
 
 		return 0, fmt.Errorf("failed to compile:\n%v\n:\n%v", source, log)
 	}
@@ -112,29 +126,33 @@ func newProgram(vertexShaderSource, fragmentShaderSource string) (uint32, error)
 
 	fragmentShader, err := compileShader(fragmentShaderSource, gl.FRAGMENT_SHADER)
 	if err != nil {
+		glb.DeleteShader(vertexShader)
 		return 0, err
 	}
 
-	program := gl.CreateProgram()
+	program := glb.CreateProgram()
 
-	gl.AttachShader(program, vertexShader)
-	gl.AttachShader(program, fragmentShader)
-	gl.LinkProgram(program)
+	glb.AttachShader(program, vertexShader)
+	glb.AttachShader(program, fragmentShader)
+	glb.LinkProgram(program)
 
 	var status int32
-	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	glb.GetProgramiv(program, gl.LINK_STATUS, &status)
 	if status == gl.FALSE {
 		var logLength int32
-		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+		glb.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
 
 		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
+		glb.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
+		glb.DeleteProgram(program)
+		glb.DeleteShader(vertexShader)
+		glb.DeleteShader(fragmentShader)
 
 		return 0, fmt.Errorf("failed to link program:\n %v", log)
 	}
 
-	gl.DeleteShader(vertexShader)
-	gl.DeleteShader(fragmentShader)
+	glb.DeleteShader(vertexShader)
+	glb.DeleteShader(fragmentShader)
 
 	return program, nil
 }
@@ -154,13 +172,20 @@ type ShaderTr struct {
 	ModelUni      int32
 	LightUni      int32
 	AmbientUni    int32
+	ClipMinUni    int32
+	ClipMaxUni    int32
 }
 
 // MakeShaderTr compiles  mki3d shader and
 // returns ShaderTr structure with reference to the program and its attributes and uniforms
 // or error
 func MakeShaderTr() (shaderPtr *ShaderTr, err error) {
-	program, err := newProgram(vertexShaderT, fragmentShader)
+	return makeShaderTr(vertexShaderT)
+}
+
+// makeShaderTr compiles mki3d shader for drawing triangles with given vertex shader source.
+func makeShaderTr(vertexShaderSource string) (shaderPtr *ShaderTr, err error) {
+	program, err := newProgram(vertexShaderSource, fragmentShader)
 	if err != nil {
 		return nil, err
 	}
@@ -170,19 +195,21 @@ func MakeShaderTr() (shaderPtr *ShaderTr, err error) {
 	// set ProgramId
 	shader.ProgramId = program
 
-	// gl.BindFragDataLocation(program, 0, gl.Str("outputColor\x00")) // test
+	// glb.BindFragDataLocation(program, 0, gl.Str("outputColor\x00")) // test
 
 	// set attributes
-	shader.PositionAttr = uint32(gl.GetAttribLocation(program, gl.Str("position\x00")))
-	shader.NormalAttr = uint32(gl.GetAttribLocation(program, gl.Str("normal\x00")))
-	shader.ColorAttr = uint32(gl.GetAttribLocation(program, gl.Str("color\x00")))
+	shader.PositionAttr = uint32(glb.GetAttribLocation(program, gl.Str("position\x00")))
+	shader.NormalAttr = uint32(glb.GetAttribLocation(program, gl.Str("normal\x00")))
+	shader.ColorAttr = uint32(glb.GetAttribLocation(program, gl.Str("color\x00")))
 
 	// set uniforms
-	shader.ProjectionUni = gl.GetUniformLocation(program, gl.Str("projection\x00"))
-	shader.ViewUni = gl.GetUniformLocation(program, gl.Str("view\x00"))
-	shader.ModelUni = gl.GetUniformLocation(program, gl.Str("model\x00"))
-	shader.LightUni = gl.GetUniformLocation(program, gl.Str("light\x00"))
-	shader.AmbientUni = gl.GetUniformLocation(program, gl.Str("ambient\x00"))
+	shader.ProjectionUni = glb.GetUniformLocation(program, gl.Str("projection\x00"))
+	shader.ViewUni = glb.GetUniformLocation(program, gl.Str("view\x00"))
+	shader.ModelUni = glb.GetUniformLocation(program, gl.Str("model\x00"))
+	shader.LightUni = glb.GetUniformLocation(program, gl.Str("light\x00"))
+	shader.AmbientUni = glb.GetUniformLocation(program, gl.Str("ambient\x00"))
+	shader.ClipMinUni = glb.GetUniformLocation(program, gl.Str("clipMin\x00"))
+	shader.ClipMaxUni = glb.GetUniformLocation(program, gl.Str("clipMax\x00"))
 	return &shader, nil
 }
 
@@ -198,13 +225,20 @@ type ShaderSeg struct {
 	ProjectionUni int32
 	ViewUni       int32
 	ModelUni      int32
+	ClipMinUni    int32
+	ClipMaxUni    int32
 }
 
 // MakeShaderSeg compiles  mki3d shader and
 // returns ShaderSeg structure with reference to the program and its attributes and uniforms
 // or error.
 func MakeShaderSeg() (shaderPtr *ShaderSeg, err error) {
-	program, err := newProgram(vertexShaderS, fragmentShader)
+	return makeShaderSeg(vertexShaderS)
+}
+
+// makeShaderSeg compiles mki3d shader for drawing segments with given vertex shader source.
+func makeShaderSeg(vertexShaderSource string) (shaderPtr *ShaderSeg, err error) {
+	program, err := newProgram(vertexShaderSource, fragmentShader)
 	if err != nil {
 		return nil, err
 	}
@@ -215,13 +249,15 @@ func MakeShaderSeg() (shaderPtr *ShaderSeg, err error) {
 	shader.ProgramId = program
 
 	// set attributes
-	shader.PositionAttr = uint32(gl.GetAttribLocation(program, gl.Str("position\x00")))
-	shader.ColorAttr = uint32(gl.GetAttribLocation(program, gl.Str("color\x00")))
+	shader.PositionAttr = uint32(glb.GetAttribLocation(program, gl.Str("position\x00")))
+	shader.ColorAttr = uint32(glb.GetAttribLocation(program, gl.Str("color\x00")))
 
 	// set uniforms
-	shader.ProjectionUni = gl.GetUniformLocation(program, gl.Str("projection\x00"))
-	shader.ViewUni = gl.GetUniformLocation(program, gl.Str("view\x00"))
-	shader.ModelUni = gl.GetUniformLocation(program, gl.Str("model\x00"))
+	shader.ProjectionUni = glb.GetUniformLocation(program, gl.Str("projection\x00"))
+	shader.ViewUni = glb.GetUniformLocation(program, gl.Str("view\x00"))
+	shader.ModelUni = glb.GetUniformLocation(program, gl.Str("model\x00"))
+	shader.ClipMinUni = glb.GetUniformLocation(program, gl.Str("clipMin\x00"))
+	shader.ClipMaxUni = glb.GetUniformLocation(program, gl.Str("clipMax\x00"))
 	return &shader, nil
 }
 
@@ -252,3 +288,10 @@ func MakeShader() (shaderPtr *Shader, err error) {
 	return &Shader{SegPtr: shaderSeg, TrPtr: shaderTr, TexPtr: shaderTex}, err
 
 }
+
+// Delete the programs of the shaders in GL, when they are not needed any more
+func (shader *Shader) Delete() {
+	glb.DeleteProgram(shader.SegPtr.ProgramId)
+	glb.DeleteProgram(shader.TrPtr.ProgramId)
+	glb.DeleteProgram(shader.TexPtr.ProgramId)
+}
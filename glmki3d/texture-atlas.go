@@ -0,0 +1,184 @@
+package glmki3d
+
+import (
+	"errors"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/mki1967/go-mki3d/mki3d"
+	"strconv"
+)
+
+/* texture atlas with all textures of a model */
+
+// GenerateAtlasTexture generates in ctx the textures defined with defs and copies them into a new atlas texture
+// with the layout atlas (the textures are generated with the size atlas.TileSize x atlas.TileSize).
+// The padding around each texture is filled with the wrapped texture.
+// Mipmaps of the atlas are generated, but their smallest levels mix neighbouring textures.
+// It returns an error if the atlas is larger than GL_MAX_TEXTURE_SIZE (use separate textures then).
+func GenerateAtlasTexture(ctx *Context, defs []mki3d.TexturionDefType, atlas *mki3d.AtlasType) (textureId uint32, err error) {
+	if err := ctx.check(); err != nil {
+		return 0, err
+	}
+	var maxSize int32
+	glb.GetIntegerv(gl.MAX_TEXTURE_SIZE, &maxSize)
+	if atlas.Width > int(maxSize) || atlas.Height > int(maxSize) {
+		return 0, errors.New("atlas.Width or atlas.Height > GL_MAX_TEXTURE_SIZE (" + strconv.Itoa(int(maxSize)) + ")")
+	}
+	options := TextureOptions{Width: atlas.TileSize, Height: atlas.TileSize}.normalized()
+	if options.Width != atlas.TileSize {
+		return 0, errors.New("atlas.TileSize > GL_MAX_TEXTURE_SIZE")
+	}
+	tileSize := int32(atlas.TileSize)
+
+	glb.GenTextures(1, &textureId)
+	glb.ActiveTexture(gl.TEXTURE0 + 0)
+	glb.BindTexture(gl.TEXTURE_2D, textureId)
+	glb.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(atlas.Width), int32(atlas.Height), 0, /* border */
+		gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(nil))
+	glb.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	glb.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+	glb.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	glb.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+
+	frameBufferId := ctx.frameBuffer()
+
+	// remember default FrameBuffer Object
+	var defaultFBO int32
+	glb.GetIntegerv(gl.FRAMEBUFFER_BINDING, &defaultFBO)
+
+	// ranges (destination offset, source offset, length) of the padding before, the texture and the padding after
+	p := int32(atlas.Padding)
+	ranges := func(origin int32) [3][3]int32 {
+		return [3][3]int32{
+			{origin - p, tileSize - p, p},
+			{origin, 0, tileSize},
+			{origin + tileSize, 0, p},
+		}
+	}
+
+	for i, def := range defs {
+		texture, err := GenerateTextureWithOptions(ctx, def, options)
+		if err != nil {
+			glb.DeleteTextures(1, &textureId)
+			return 0, err
+		}
+
+		// read from the generated texture
+		glb.BindFramebuffer(gl.FRAMEBUFFER, frameBufferId)
+		glb.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, texture, 0)
+		glb.BindTexture(gl.TEXTURE_2D, textureId)
+		x, y := atlas.TileOrigin(i)
+		for _, rx := range ranges(int32(x)) {
+			for _, ry := range ranges(int32(y)) {
+				if rx[2] > 0 && ry[2] > 0 {
+					glb.CopyTexSubImage2D(gl.TEXTURE_2D, 0, rx[0], ry[0], rx[1], ry[1], rx[2], ry[2])
+				}
+			}
+		}
+		glb.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, 0, 0)
+		glb.BindFramebuffer(gl.FRAMEBUFFER, uint32(defaultFBO)) // return to default screen FBO
+		glb.DeleteTextures(1, &texture)
+	}
+
+	glb.BindTexture(gl.TEXTURE_2D, textureId)
+	glb.GenerateMipmap(gl.TEXTURE_2D)
+
+	return textureId, nil
+}
+
+// MakeGLDataTexAtlas either returns pointer to a new GLDataTexEl with all textured triangles of texture
+// mapped to the atlas texture generated in ctx from the definitions of the texture elements or an error.
+func MakeGLDataTexAtlas(ctx *Context, texture *mki3d.TextureType, atlas *mki3d.AtlasType, shaderPtr *ShaderTex, layout BufferLayout) (*GLDataTexEl, error) {
+	if shaderPtr == nil {
+		return nil, errors.New("shaderPtr == nil // type *ShaderTex")
+	}
+
+	glData := GLDataTexEl{Layout: layout, CtxPtr: ctx}
+	glData.genBuffers()
+
+	texEl := mki3d.TextureElementType{TexturedTriangles: texture.AtlasTriangles(atlas)}
+	glData.LoadTriangleBufs(&texEl)
+
+	defs := make([]mki3d.TexturionDefType, 0, len(texture.Elements))
+	for _, el := range texture.Elements {
+		defs = append(defs, el.Def)
+	}
+	textureId, err := GenerateAtlasTexture(ctx, defs, atlas)
+	if err != nil {
+		glData.Delete()
+		return nil, err
+	}
+	glData.Texture = textureId
+
+	glData.InitVAO(shaderPtr)
+
+	return &glData, nil
+}
+
+// MakeDataShaderTexAtlas works as MakeDataShaderTexWithLayout, but all textures are packed into one atlas texture
+// with the given padding and all textured triangles are drawn with a single draw call.
+// It returns an error if the atlas would be larger than GL_MAX_TEXTURE_SIZE.
+// If clamp is true, then UV coordinates outside of [0,1] are clamped instead of splitting the triangles
+// at the borders of the texture repetitions.
+func MakeDataShaderTexAtlas(ctx *Context, uPtr *GLUni, mPtr *mki3d.Mki3dType, layout BufferLayout, padding int, clamp bool) (dsPtr *DataShaderTex, err error) {
+	if mPtr == nil {
+		return nil, errors.New("mPtr == nil // type *Mki3dType ")
+	}
+	if mPtr.Texture == nil { // there are no textures
+		return nil, nil
+	}
+	if err := ctx.check(); err != nil {
+		return nil, err
+	}
+	sPtr := ctx.ShaderPtr.TexPtr
+	if uPtr == nil {
+		return nil, errors.New("uPtr == nil // type *GLUni ")
+	}
+
+	atlas := mki3d.MakeAtlas(len(mPtr.Texture.Elements), texSize, padding)
+	atlas.Clamp = clamp
+	dataElement, err := MakeGLDataTexAtlas(ctx, mPtr.Texture, atlas, sPtr, layout)
+	if err != nil {
+		return nil, err
+	}
+
+	ds := DataShaderTex{ShaderPtr: sPtr, DataElements: []*GLDataTexEl{dataElement}, UniPtr: uPtr, Mki3dPtr: mPtr,
+		Layout: layout, AtlasPtr: atlas, CtxPtr: ctx}
+
+	return &ds, nil
+}
+
+// updateAtlas rebuilds the atlas of ds from the texture elements of ds.Mki3dPtr.Texture
+func (ds *DataShaderTex) updateAtlas() error {
+	for _, dataElement := range ds.DataElements {
+		dataElement.Delete()
+	}
+	ds.DataElements = nil
+	if ds.Mki3dPtr.Texture == nil {
+		return nil
+	}
+
+	atlas := mki3d.MakeAtlas(len(ds.Mki3dPtr.Texture.Elements), texSize, ds.AtlasPtr.Padding)
+	atlas.Clamp = ds.AtlasPtr.Clamp
+	dataElement, err := MakeGLDataTexAtlas(ds.CtxPtr, ds.Mki3dPtr.Texture, atlas, ds.ShaderPtr, ds.Layout)
+	if err != nil {
+		return err
+	}
+	ds.DataElements = []*GLDataTexEl{dataElement}
+	ds.AtlasPtr = atlas
+	return nil
+}
+
+// UseAtlas replaces the texture data of ds with a single atlas texture (see MakeDataShaderTexAtlas).
+func (ds *DataShader) UseAtlas(padding int, clamp bool) error {
+	texPtr, err := MakeDataShaderTexAtlas(ds.CtxPtr, ds.UniPtr, ds.Mki3dPtr, ds.Layout, padding, clamp)
+	if err != nil {
+		return err
+	}
+	if ds.TexPtr != nil {
+		for _, dataElement := range ds.TexPtr.DataElements {
+			dataElement.Delete()
+		}
+	}
+	ds.TexPtr = texPtr
+	return nil
+}
@@ -0,0 +1,42 @@
+package glmki3d
+
+import (
+	"github.com/mki1967/go-mki3d/mki3d"
+	"testing"
+)
+
+func TestMakeDataShaderTexAtlasTooLarge(t *testing.T) {
+	fake := useFakeGL(t)
+	ctx, err := MakeContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	m := testModel()
+	for i := 0; i < 3; i++ { // 5 textures in 3 columns and 2 rows
+		m.Texture.Elements = append(m.Texture.Elements, m.Texture.Elements[0])
+	}
+	padding := 8
+	atlas := mki3d.MakeAtlas(len(m.Texture.Elements), texSize, padding)
+	fake.MaxTextureSize = int32(atlas.Width - 1) // the tiles fit, but the atlas does not
+
+	liveObjects := dataObjects(fake, ctx)
+	if _, err := MakeDataShaderTexAtlas(ctx, MakeGLUni(), m, LayoutSeparate, padding, false); err == nil {
+		t.Errorf("%dx%d atlas accepted with GL_MAX_TEXTURE_SIZE = %d", atlas.Width, atlas.Height, fake.MaxTextureSize)
+	}
+	if dataObjects(fake, ctx) != liveObjects {
+		t.Errorf("%d objects left in GL", dataObjects(fake, ctx)-liveObjects)
+	}
+
+	fake.MaxTextureSize = int32(atlas.Width)
+	texPtr, err := MakeDataShaderTexAtlas(ctx, MakeGLUni(), m, LayoutSeparate, padding, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tex := fake.Textures[texPtr.DataElements[0].Texture]
+	if tex == nil || int(tex.Width) != atlas.Width || int(tex.Height) != atlas.Height {
+		t.Errorf("atlas texture %+v, want %dx%d", tex, atlas.Width, atlas.Height)
+	}
+	texPtr.DataElements[0].Delete()
+}
@@ -0,0 +1,99 @@
+package glmki3d
+
+import (
+	"github.com/mki1967/go-mki3d/mki3d"
+)
+
+/* reference counted cache of generated textures */
+
+// textureCacheKey identifies a generated texture
+type textureCacheKey struct {
+	Def     mki3d.TexturionDefType // normalized definition
+	Options TextureOptions         // normalized options
+}
+
+// textureCacheEntry is a generated texture with the number of its users
+type textureCacheEntry struct {
+	Texture uint32
+	Count   int
+}
+
+// TextureCache shares GL textures generated from equal (normalized) Texturion definitions with equal options.
+// The cache can be shared by many DataShaders of its Context.
+type TextureCache struct {
+	ctx     *Context // context in which the textures are generated
+	entries map[textureCacheKey]*textureCacheEntry
+	keys    map[uint32]textureCacheKey // keys of the cached textures
+}
+
+// MakeTextureCache returns a pointer to a new empty TextureCache for the textures generated in ctx
+// (each Context has its own cache ctx.CachePtr)
+func MakeTextureCache(ctx *Context) *TextureCache {
+	return &TextureCache{ctx: ctx, entries: make(map[textureCacheKey]*textureCacheEntry), keys: make(map[uint32]textureCacheKey)}
+}
+
+// Acquire either returns GL ID of the texture generated from def with options or an error.
+// The texture is generated only if it is not already in the cache.
+// Each acquired texture should be released with Release.
+func (cache *TextureCache) Acquire(def mki3d.TexturionDefType, options TextureOptions) (textureId uint32, err error) {
+	key := textureCacheKey{Def: def.Normalized(), Options: options.normalized()}
+	if entry, ok := cache.entries[key]; ok {
+		entry.Count++
+		return entry.Texture, nil
+	}
+	textureId, err = GenerateTextureWithOptions(cache.ctx, key.Def, key.Options)
+	if err != nil {
+		return 0, err
+	}
+	cache.entries[key] = &textureCacheEntry{Texture: textureId, Count: 1}
+	cache.keys[textureId] = key
+	return textureId, nil
+}
+
+// Release decrements the number of users of the texture textureId and deletes the texture in GL if it is not used any more.
+// It returns false if textureId is not in the cache.
+func (cache *TextureCache) Release(textureId uint32) bool {
+	key, ok := cache.keys[textureId]
+	if !ok {
+		return false
+	}
+	entry := cache.entries[key]
+	entry.Count--
+	if entry.Count <= 0 {
+		glb.DeleteTextures(1, &entry.Texture)
+		delete(cache.entries, key)
+		delete(cache.keys, textureId)
+	}
+	return true
+}
+
+// Len returns the number of textures in the cache
+func (cache *TextureCache) Len() int {
+	return len(cache.entries)
+}
+
+// Delete deletes all textures of the cache in GL (even if they are still used), when they are not needed any more
+func (cache *TextureCache) Delete() {
+	for key, entry := range cache.entries {
+		glb.DeleteTextures(1, &entry.Texture)
+		delete(cache.entries, key)
+	}
+	cache.keys = make(map[uint32]textureCacheKey)
+}
+
+// releaseTexture releases the texture of glData to glData.CachePtr or deletes it if it is not cached
+func (glData *GLDataTexEl) releaseTexture() {
+	if glData.CachePtr == nil || !glData.CachePtr.Release(glData.Texture) {
+		glb.DeleteTextures(1, &glData.Texture)
+	}
+	glData.Texture = 0
+}
+
+// generateTexture either returns GL ID of the texture generated in glData.CtxPtr from def with options
+// (acquired from glData.CachePtr if not nil) or an error
+func (glData *GLDataTexEl) generateTexture(def mki3d.TexturionDefType, options TextureOptions) (uint32, error) {
+	if glData.CachePtr != nil {
+		return glData.CachePtr.Acquire(def, options)
+	}
+	return GenerateTextureWithOptions(glData.CtxPtr, def, options)
+}
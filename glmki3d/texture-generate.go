@@ -12,7 +12,13 @@ import (
 
 const texSize = 256
 
+// MakeGeneratorVertexShader returns the source of the vertex shader generating the texSize x texSize texture defined with def.
 func MakeGeneratorVertexShader(def mki3d.TexturionDefType) string {
+	return MakeGeneratorVertexShaderWithSize(def, texSize, texSize)
+}
+
+// MakeGeneratorVertexShaderWithSize returns the source of the vertex shader generating the width x height texture defined with def.
+func MakeGeneratorVertexShaderWithSize(def mki3d.TexturionDefType, width, height int) string {
 	test := strings.Join([]string{def.R, def.G, def.B, def.A}, "")
 	if strings.ContainsAny(test, ";}") { // security check failed -> replace with black.
 		def.R = "0.0"
@@ -23,7 +29,8 @@ func MakeGeneratorVertexShader(def mki3d.TexturionDefType) string {
 	return "" +
 		"#version 330\n" +
 		"const float PI = " + strconv.FormatFloat(math.Pi, 'f', -1, 64) + ";\n" +
-		"const int texSize= " + strconv.Itoa(texSize) + ";\n" +
+		"const int texWidth= " + strconv.Itoa(width) + ";\n" +
+		"const int texHeight= " + strconv.Itoa(height) + ";\n" +
 		"float G(float x,float y);\n" +
 		"float B(float x,float y);\n" +
 		"float A(float x,float y);\n" +
@@ -37,10 +44,10 @@ func MakeGeneratorVertexShader(def mki3d.TexturionDefType) string {
 		"void main()\n" +
 		"{\n" +
 		"  float  args[6];\n" +
-		"  float h=h-float(texSize)/2.0;\n" +
-		"  float v=v-float(texSize)/2.0;\n" +
-		"  float x= 2.0*h/float(texSize); \n" +
-		"  float y= 2.0*v/float(texSize); \n" +
+		"  float h=h-float(texWidth)/2.0;\n" +
+		"  float v=v-float(texHeight)/2.0;\n" +
+		"  float x= 2.0*h/float(texWidth); \n" +
+		"  float y= 2.0*v/float(texHeight); \n" +
 		"  color= vec4( R(x,y), G(x,y), B(x,y), A(x,y) );\n" +
 		"  gl_Position = vec4( x, y, 0.0, 1.0 );\n" + /// w=0.5 for perspective division
 		"  gl_PointSize=1.0;\n" + /// test it
@@ -62,122 +69,114 @@ void main()
 // MakeGeneratorShaderProgram makes new GL shader program for generating the texture defined with def and
 // returns its GL ID.
 func MakeGeneratorShaderProgram(def mki3d.TexturionDefType) (programId uint32, err error) {
-	vertexShader := MakeGeneratorVertexShader(def)
+	return MakeGeneratorShaderProgramWithSize(def, texSize, texSize)
+}
+
+// MakeGeneratorShaderProgramWithSize works as MakeGeneratorShaderProgram for the width x height texture.
+func MakeGeneratorShaderProgramWithSize(def mki3d.TexturionDefType, width, height int) (programId uint32, err error) {
+	vertexShader := MakeGeneratorVertexShaderWithSize(def, width, height)
 	// fmt.Printf("vertexShader:\n%v\n", vertexShader)                       //// test
 	// fmt.Printf("GeneratorFragmentShader:\n%v\n", GeneratorFragmentShader) //// test
 	return NewProgram(vertexShader, GeneratorFragmentShader)
 }
 
-// hBuffer is an auxiliary buffer used for texture generation
-var hBufferId uint32
-var hBufferIdExists = false
-
-// frameBuffer is a frame to which a texture image is attached to be drawed on
-var frameBufferId uint32
-var frameBufferIdExists = false
+// GenerateTexture either returns GL ID of a new texture generated in ctx from def with DefaultTextureOptions or an error.
+func GenerateTexture(ctx *Context, def mki3d.TexturionDefType) (textureId uint32, err error) {
+	return GenerateTextureWithOptions(ctx, def, DefaultTextureOptions)
+}
 
-func GenerateTexture(def mki3d.TexturionDefType) (textureId uint32, err error) {
-	renderTextureShaderProgram, err := MakeGeneratorShaderProgram(def)
+// GenerateTextureWithOptions either returns GL ID of a new texture generated in ctx from def with the given options or an error.
+// The size of the texture is limited by GL_MAX_TEXTURE_SIZE.
+func GenerateTextureWithOptions(ctx *Context, def mki3d.TexturionDefType, options TextureOptions) (textureId uint32, err error) {
+	if err := ctx.check(); err != nil {
+		return 0, err
+	}
+	options = options.normalized()
+	width, height := options.Width, options.Height
+	renderTextureShaderProgram, err := MakeGeneratorShaderProgramWithSize(def, width, height)
 
 	if err != nil {
 		return 0, err
 	}
 
-	// gl.BindFragDataLocation(renderTextureShaderProgram, 0, gl.Str("out_FragColor\x00")) // test
+	// glb.BindFragDataLocation(renderTextureShaderProgram, 0, gl.Str("out_FragColor\x00")) // test
 
 	/* set vertex attributes locations */
-	hLocation := gl.GetAttribLocation(renderTextureShaderProgram, gl.Str("h\x00"))
+	hLocation := glb.GetAttribLocation(renderTextureShaderProgram, gl.Str("h\x00"))
 	if hLocation < 0 {
 		panic("hLocation=" + strconv.Itoa(int(hLocation)))
 	}
 
 	/* set uniform variables locations */
-	vLocation := gl.GetUniformLocation(renderTextureShaderProgram, gl.Str("v\x00"))
+	vLocation := glb.GetUniformLocation(renderTextureShaderProgram, gl.Str("v\x00"))
 	if vLocation < 0 {
 		panic("vLocation=" + strconv.Itoa(int(vLocation)))
 	}
 
 	/* load hBuffer data if needed */
-	if hBufferIdExists == false {
-		gl.GenBuffers(1, &hBufferId)
-		gl.BindBuffer(gl.ARRAY_BUFFER, hBufferId)
-
-		var hIn [texSize + 4]float32
-		for i := 0; i < texSize+4; i++ {
-			hIn[i] = float32(i - 2)
-		}
-		gl.BufferData(gl.ARRAY_BUFFER, len(hIn)*4 /* 4 bytes per float32 */, gl.Ptr(&hIn[0]), gl.STATIC_DRAW)
-		hBufferIdExists = true
-	}
+	hBufferId := ctx.hBuffer(width + 4)
 
 	/* init VAO */
 	var renderTextureVAO uint32
-	gl.UseProgram(renderTextureShaderProgram)
-	gl.GenVertexArrays(1, &renderTextureVAO)
-	gl.BindVertexArray(renderTextureVAO)
+	glb.UseProgram(renderTextureShaderProgram)
+	glb.GenVertexArrays(1, &renderTextureVAO)
+	glb.BindVertexArray(renderTextureVAO)
 
-	gl.BindBuffer(gl.ARRAY_BUFFER, hBufferId)
-	gl.EnableVertexAttribArray(uint32(hLocation))
-	gl.VertexAttribPointer(uint32(hLocation), 1, gl.FLOAT, false, 0, gl.PtrOffset(0))
-	gl.BindVertexArray(0) // unbind VAO
+	glb.BindBuffer(gl.ARRAY_BUFFER, hBufferId)
+	glb.EnableVertexAttribArray(uint32(hLocation))
+	glb.VertexAttribPointer(uint32(hLocation), 1, gl.FLOAT, false, 0, gl.PtrOffset(0))
+	glb.BindVertexArray(0) // unbind VAO
 
-	gl.GenTextures(1, &textureId)
+	glb.GenTextures(1, &textureId)
 	/// TO DO: check textureId
 
-	gl.ActiveTexture(gl.TEXTURE0 + 0)
+	glb.ActiveTexture(gl.TEXTURE0 + 0)
 
 	// set texture type, image and parameters
-	gl.BindTexture(gl.TEXTURE_2D, textureId)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, texSize, texSize, 0, /* border */
+	glb.BindTexture(gl.TEXTURE_2D, textureId)
+	glb.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(width), int32(height), 0, /* border */
 		gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(nil))
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
-
-	if frameBufferIdExists == false {
-		/* create framebuffer object */
-		gl.GenFramebuffers(1, &frameBufferId)
-		frameBufferIdExists = true
-	}
+	setTextureParameters(options)
+
+	frameBufferId := ctx.frameBuffer()
 
 	// remember default FrameBuffer Object
 	var defaultFBO int32
-	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &defaultFBO)
+	glb.GetIntegerv(gl.FRAMEBUFFER_BINDING, &defaultFBO)
 
 	// remember viewport
 	var viewport [4]int32
-	gl.GetIntegerv(gl.VIEWPORT, &viewport[0]) // save viewport parameters
+	glb.GetIntegerv(gl.VIEWPORT, &viewport[0]) // save viewport parameters
 
-	gl.UseProgram(renderTextureShaderProgram)
+	glb.UseProgram(renderTextureShaderProgram)
 
 	// fmt.Printf("frameBufferId = %v\n", frameBufferId)
-	gl.BindFramebuffer(gl.FRAMEBUFFER, frameBufferId)
-	gl.Viewport(0, 0, texSize, texSize)
+	glb.BindFramebuffer(gl.FRAMEBUFFER, frameBufferId)
+	glb.Viewport(0, 0, int32(width), int32(height))
 
-	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, textureId, 0)
+	glb.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, textureId, 0)
 
 	// fmt.Printf("hBufferId = %v\n", hBufferId)
-	// gl.BindBuffer(gl.ARRAY_BUFFER, hBufferId)
-	// gl.EnableVertexAttribArray(uint32(hLocation))
-
-	gl.BindVertexArray(renderTextureVAO)
-	for j := 0; j < texSize+4; j++ {
-		// gl.VertexAttribPointer(uint32(hLocation), 1, gl.FLOAT, false, 0, gl.PtrOffset(0)) /// in the loop ?
-		gl.Uniform1f(vLocation, float32(j-2))
-		gl.DrawArrays(gl.POINTS, 0, texSize+4)
+	// glb.BindBuffer(gl.ARRAY_BUFFER, hBufferId)
+	// glb.EnableVertexAttribArray(uint32(hLocation))
+
+	glb.BindVertexArray(renderTextureVAO)
+	for j := 0; j < height+4; j++ {
+		// glb.VertexAttribPointer(uint32(hLocation), 1, gl.FLOAT, false, 0, gl.PtrOffset(0)) /// in the loop ?
+		glb.Uniform1f(vLocation, float32(j-2))
+		glb.DrawArrays(gl.POINTS, 0, int32(width+4))
 	}
-	gl.BindVertexArray(0) // unbind
-	// gl.DisableVertexAttribArray(uint32(hLocation))
+	glb.BindVertexArray(0) // unbind
+	// glb.DisableVertexAttribArray(uint32(hLocation))
 
-	gl.BindTexture(gl.TEXTURE_2D, textureId)
-	gl.GenerateMipmap(gl.TEXTURE_2D)
+	glb.BindTexture(gl.TEXTURE_2D, textureId)
+	glb.GenerateMipmap(gl.TEXTURE_2D)
 
-	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(defaultFBO))          // return to default screen FBO
-	gl.Viewport(viewport[0], viewport[1], viewport[2], viewport[3]) // restore viewport
+	glb.BindFramebuffer(gl.FRAMEBUFFER, uint32(defaultFBO))          // return to default screen FBO
+	glb.Viewport(viewport[0], viewport[1], viewport[2], viewport[3]) // restore viewport
 
-	gl.DeleteVertexArrays(1, &renderTextureVAO)
-	gl.DeleteProgram(renderTextureShaderProgram) // delete used program
+	glb.DeleteVertexArrays(1, &renderTextureVAO)
+	glb.DeleteProgram(renderTextureShaderProgram) // delete used program
 
 	return textureId, nil
 }
@@ -0,0 +1,122 @@
+package glmki3d
+
+import (
+	"errors"
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/mki1967/go-mki3d/mki3d"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+/* reading of generated textures back to images */
+
+// TextureImage either returns the image of the level 0 of the GL texture textureId or an error.
+// The rows are flipped, so that the top of the image is at V = 1 (y = 1 in Texturion).
+// The bound texture and the pack alignment are restored.
+func TextureImage(textureId uint32) (*image.NRGBA, error) {
+	// remember bound texture and pack alignment
+	var boundTexture, packAlignment int32
+	glb.GetIntegerv(gl.TEXTURE_BINDING_2D, &boundTexture)
+	glb.GetIntegerv(gl.PACK_ALIGNMENT, &packAlignment)
+	defer func() {
+		glb.BindTexture(gl.TEXTURE_2D, uint32(boundTexture))
+		glb.PixelStorei(gl.PACK_ALIGNMENT, packAlignment)
+	}()
+
+	glb.BindTexture(gl.TEXTURE_2D, textureId)
+	var width, height int32
+	glb.GetTexLevelParameteriv(gl.TEXTURE_2D, 0, gl.TEXTURE_WIDTH, &width)
+	glb.GetTexLevelParameteriv(gl.TEXTURE_2D, 0, gl.TEXTURE_HEIGHT, &height)
+	if width <= 0 || height <= 0 {
+		return nil, errors.New("texture " + strconv.Itoa(int(textureId)) + " has no image")
+	}
+
+	pixels := make([]uint8, 4*width*height)
+	glb.PixelStorei(gl.PACK_ALIGNMENT, 4) // rows of RGBA pixels are always aligned to 4 bytes
+	glb.GetTexImage(gl.TEXTURE_2D, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(&pixels[0]))
+
+	img := image.NewNRGBA(image.Rect(0, 0, int(width), int(height)))
+	rowSize := 4 * int(width)
+	for y := 0; y < int(height); y++ {
+		row := pixels[(int(height)-1-y)*rowSize : (int(height)-y)*rowSize]
+		copy(img.Pix[y*img.Stride:y*img.Stride+rowSize], row)
+	}
+	return img, nil
+}
+
+// Image either returns the image of the texture of glData (see TextureImage) or an error.
+func (glData *GLDataTexEl) Image() (*image.NRGBA, error) {
+	return TextureImage(glData.Texture)
+}
+
+// GenerateTextureImage either returns the image of the texture generated in ctx from def with options or an error.
+// The GL texture is deleted.
+func GenerateTextureImage(ctx *Context, def mki3d.TexturionDefType, options TextureOptions) (*image.NRGBA, error) {
+	textureId, err := GenerateTextureWithOptions(ctx, def, options)
+	if err != nil {
+		return nil, err
+	}
+	defer glb.DeleteTextures(1, &textureId)
+	return TextureImage(textureId)
+}
+
+// textureFileName returns the name of the file of the texture with the label and the index i,
+// where the characters other than letters, digits, '-', '_' and '.' are replaced with '_'.
+func textureFileName(label string, i int) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, label)
+	if strings.Trim(name, ".") == "" {
+		name = "texture-" + strconv.Itoa(i)
+	}
+	return name
+}
+
+// SaveTexturesPNG generates in ctx the textures of the texture elements of mPtr with options and saves them as PNG files
+// in the directory dir named by the labels of their definitions (with the index of the element, or the first
+// greater number giving an unused name, appended for repeated labels). It either returns the paths of the saved files (in the order of the elements) or an error.
+func SaveTexturesPNG(ctx *Context, mPtr *mki3d.Mki3dType, dir string, options TextureOptions) (paths []string, err error) {
+	if mPtr == nil {
+		return nil, errors.New("mPtr == nil // type *Mki3dType ")
+	}
+	if mPtr.Texture == nil {
+		return nil, nil
+	}
+
+	used := make(map[string]bool)
+	for i, texEl := range mPtr.Texture.Elements {
+		name := textureFileName(texEl.Def.Label, i)
+		base := name
+		for suffix := i; used[name]; suffix++ {
+			name = base + "-" + strconv.Itoa(suffix)
+		}
+		used[name] = true
+
+		img, err := GenerateTextureImage(ctx, texEl.Def, options)
+		if err != nil {
+			return paths, err
+		}
+		path := filepath.Join(dir, name+".png")
+		file, err := os.Create(path)
+		if err != nil {
+			return paths, err
+		}
+		err = png.Encode(file, img)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
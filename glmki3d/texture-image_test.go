@@ -0,0 +1,80 @@
+package glmki3d
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/mki1967/go-mki3d/mki3d"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveTexturesPNGNames(t *testing.T) {
+	useFakeGL(t)
+	ctx, err := MakeContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	m := &mki3d.Mki3dType{Texture: &mki3d.TextureType{}}
+	for _, label := range []string{"a-2", "a", "a", "a/3", ""} {
+		def := mki3d.TexturionDefType{Label: label, R: "1", G: "0", B: "0", A: "1"}
+		m.Texture.Elements = append(m.Texture.Elements, mki3d.TextureElementType{Def: def})
+	}
+	dir := t.TempDir()
+	small := TextureOptions{Width: 4, Height: 4}
+	paths, err := SaveTexturesPNG(ctx, m, dir, small)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"a-2.png", "a.png", "a-3.png", "a_3.png", "texture-4.png"}
+	if len(paths) != len(want) {
+		t.Fatalf("paths %v, want %v", paths, want)
+	}
+	for i, path := range paths {
+		if path != filepath.Join(dir, want[i]) {
+			t.Errorf("path %d = %s, want %s", i, path, want[i])
+		}
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(want) {
+		t.Errorf("%d files saved, want %d", len(files), len(want))
+	}
+}
+
+func TestTextureImageRestoresState(t *testing.T) {
+	fake := useFakeGL(t)
+	ctx, err := MakeContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	def := mki3d.TexturionDefType{R: "x", G: "y", B: "0", A: "1"}
+	textureId, err := GenerateTextureWithOptions(ctx, def, TextureOptions{Width: 8, Height: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bound uint32
+	fake.GenTextures(1, &bound)
+	fake.BindTexture(gl.TEXTURE_2D, bound)
+	fake.PixelStorei(gl.PACK_ALIGNMENT, 1)
+
+	img, err := TextureImage(textureId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size.X != 8 || size.Y != 4 {
+		t.Errorf("image size %v, want 8x4", size)
+	}
+	if fake.Bindings[gl.TEXTURE_2D] != bound {
+		t.Errorf("bound texture %d, want %d", fake.Bindings[gl.TEXTURE_2D], bound)
+	}
+	if fake.PixelStore[gl.PACK_ALIGNMENT] != 1 {
+		t.Errorf("pack alignment %d, want 1", fake.PixelStore[gl.PACK_ALIGNMENT])
+	}
+}
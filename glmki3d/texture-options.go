@@ -0,0 +1,139 @@
+package glmki3d
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/mki1967/go-mki3d/mki3d"
+)
+
+/* options of generated textures */
+
+// TextureFilter selects the filtering of a texture
+type TextureFilter int
+
+const (
+	// FilterLinear - linear filtering with linear interpolation between mipmaps
+	FilterLinear TextureFilter = iota
+	// FilterNearest - the nearest texel of the nearest mipmap
+	FilterNearest
+)
+
+// TextureWrap selects the wrapping of UV coordinates outside of [0,1]
+type TextureWrap int
+
+const (
+	// WrapRepeat - the texture is repeated
+	WrapRepeat TextureWrap = iota
+	// WrapClamp - the coordinates are clamped to the edges of the texture
+	WrapClamp
+	// WrapMirror - the texture is repeated with every second repetition mirrored
+	WrapMirror
+)
+
+// TextureOptions are the parameters of generated textures.
+// The zero value gives the default texSize x texSize texture with linear filtering and repeat wrapping.
+type TextureOptions struct {
+	Width      int // width in pixels (zero means texSize), limited by GL_MAX_TEXTURE_SIZE
+	Height     int // height in pixels (zero means texSize), limited by GL_MAX_TEXTURE_SIZE
+	Filter     TextureFilter
+	Wrap       TextureWrap
+	Anisotropy float32 // maximal anisotropy (values above 1 are used only if the GL supports anisotropic filtering)
+}
+
+// DefaultTextureOptions are the options used by GenerateTexture
+var DefaultTextureOptions = TextureOptions{Width: texSize, Height: texSize, Filter: FilterLinear, Wrap: WrapRepeat}
+
+// TextureOptionsFunc returns the options of the texture of the texture element number i
+type TextureOptionsFunc func(i int, texEl *mki3d.TextureElementType) TextureOptions
+
+// SameTextureOptions returns TextureOptionsFunc giving options for all texture elements
+func SameTextureOptions(options TextureOptions) TextureOptionsFunc {
+	return func(i int, texEl *mki3d.TextureElementType) TextureOptions {
+		return options
+	}
+}
+
+// textureOptions returns the options given by f for the texture element texEl number i (default options if f is nil)
+func (f TextureOptionsFunc) textureOptions(i int, texEl *mki3d.TextureElementType) TextureOptions {
+	if f == nil {
+		return DefaultTextureOptions
+	}
+	return f(i, texEl)
+}
+
+// constants of the extension GL_EXT_texture_filter_anisotropic (core since GL 4.6)
+const (
+	textureMaxAnisotropy    = 0x84FE
+	maxTextureMaxAnisotropy = 0x84FF
+)
+
+// maxAnisotropy returns the maximal anisotropy supported by the current GL context
+// or 0 if anisotropic filtering is not available.
+func maxAnisotropy() float32 {
+	var count int32
+	glb.GetIntegerv(gl.NUM_EXTENSIONS, &count)
+	for i := int32(0); i < count; i++ {
+		switch gl.GoStr(glb.GetStringi(gl.EXTENSIONS, uint32(i))) {
+		case "GL_EXT_texture_filter_anisotropic", "GL_ARB_texture_filter_anisotropic":
+			var max float32
+			glb.GetFloatv(maxTextureMaxAnisotropy, &max)
+			return max
+		}
+	}
+	return 0
+}
+
+// normalized returns options with default sizes in place of zeros and with the values limited
+// to the capabilities of the current GL context.
+func (options TextureOptions) normalized() TextureOptions {
+	var maxSize int32
+	glb.GetIntegerv(gl.MAX_TEXTURE_SIZE, &maxSize)
+	for _, size := range []*int{&options.Width, &options.Height} {
+		if *size <= 0 {
+			*size = texSize
+		}
+		if maxSize > 0 && *size > int(maxSize) {
+			*size = int(maxSize)
+		}
+	}
+	if options.Filter != FilterNearest {
+		options.Filter = FilterLinear
+	}
+	if options.Wrap != WrapClamp && options.Wrap != WrapMirror {
+		options.Wrap = WrapRepeat
+	}
+	if options.Anisotropy <= 1 {
+		options.Anisotropy = 0
+	} else if max := maxAnisotropy(); options.Anisotropy > max {
+		options.Anisotropy = max
+		if max <= 1 {
+			options.Anisotropy = 0
+		}
+	}
+	return options
+}
+
+// setTextureParameters sets the parameters of the texture bound to TEXTURE_2D to the normalized options
+func setTextureParameters(options TextureOptions) {
+	switch options.Filter {
+	case FilterNearest:
+		glb.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
+		glb.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST_MIPMAP_NEAREST)
+	default:
+		glb.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+		glb.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+	}
+
+	wrap := int32(gl.REPEAT)
+	switch options.Wrap {
+	case WrapClamp:
+		wrap = gl.CLAMP_TO_EDGE
+	case WrapMirror:
+		wrap = gl.MIRRORED_REPEAT
+	}
+	glb.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, wrap)
+	glb.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, wrap)
+
+	if options.Anisotropy > 1 {
+		glb.TexParameterf(gl.TEXTURE_2D, textureMaxAnisotropy, options.Anisotropy)
+	}
+}
@@ -0,0 +1,155 @@
+package mki3d
+
+/* packing of textures into a texture atlas */
+
+import (
+	"math"
+)
+
+// AtlasType is a layout of square textures of the same size packed into one atlas texture.
+// The textures are placed in a grid of tiles. Each tile contains the texture surrounded by
+// Padding pixels filled with the wrapped texture (against bleeding of neighbouring textures).
+type AtlasType struct {
+	Count    int  // number of textures
+	TileSize int  // size of the textures in pixels
+	Padding  int  // pixels added on each side of each texture
+	Columns  int  // number of tiles in a row
+	Rows     int  // number of rows of tiles
+	Width    int  // width of the atlas texture in pixels
+	Height   int  // height of the atlas texture in pixels
+	Clamp    bool // if true, UV coordinates outside of [0,1] are clamped instead of splitting the triangles
+}
+
+// maxAtlasCells limits the number of texture repetitions that a triangle is split into.
+// Triangles spanning more repetitions are clamped.
+const maxAtlasCells = 64
+
+// MakeAtlas returns a pointer to the layout of the atlas for count textures of the size tileSize with the given padding.
+func MakeAtlas(count, tileSize, padding int) *AtlasType {
+	if padding > tileSize {
+		padding = tileSize
+	}
+	atlas := AtlasType{Count: count, TileSize: tileSize, Padding: padding}
+	if count > 0 {
+		atlas.Columns = int(math.Ceil(math.Sqrt(float64(count))))
+		atlas.Rows = (count + atlas.Columns - 1) / atlas.Columns
+	}
+	atlas.Width = atlas.Columns * (tileSize + 2*padding)
+	atlas.Height = atlas.Rows * (tileSize + 2*padding)
+	return &atlas
+}
+
+// TileOrigin returns the pixel coordinates of the corner of the texture i in the atlas (without padding).
+func (atlas *AtlasType) TileOrigin(i int) (x, y int) {
+	column, row := i%atlas.Columns, i/atlas.Columns
+	x = column*(atlas.TileSize+2*atlas.Padding) + atlas.Padding
+	y = row*(atlas.TileSize+2*atlas.Padding) + atlas.Padding
+	return x, y
+}
+
+// MapUV returns the UV coordinates in the atlas of the point with coordinates uv (in [0,1]) of the texture i.
+func (atlas *AtlasType) MapUV(i int, uv Vector2dType) Vector2dType {
+	x, y := atlas.TileOrigin(i)
+	size := float32(atlas.TileSize)
+	return Vector2dType{
+		(float32(x) + uv[0]*size) / float32(atlas.Width),
+		(float32(y) + uv[1]*size) / float32(atlas.Height),
+	}
+}
+
+// clipPolygonHalfPlane returns the part of the polygon where distance is non-negative
+func clipPolygonHalfPlane(polygon []clipVertex, distance func(v clipVertex) float32) []clipVertex {
+	result := make([]clipVertex, 0, len(polygon)+1)
+	for i, a := range polygon {
+		b := polygon[(i+1)%len(polygon)]
+		da, db := distance(a), distance(b)
+		if da >= 0 {
+			result = append(result, a)
+		}
+		if (da >= 0) != (db >= 0) {
+			result = append(result, interpolateVertex(a, b, da/(da-db)))
+		}
+	}
+	return result
+}
+
+// atlasPieces returns the polygons of the parts of the textured triangle within single repetitions of the texture
+// with UV coordinates shifted to [0,1]. If clamp is true (or the triangle spans too many repetitions),
+// then the triangle is only shifted and its UV coordinates are clamped to [0,1].
+func atlasPieces(texTriangle *TexturedTriangleType, clamp bool) [][]clipVertex {
+	polygon := make([]clipVertex, 3)
+	for j := 0; j < 3; j++ {
+		polygon[j] = clipVertex{Endpoint: texTriangle.Triangle[j], UV: texTriangle.TriangleUV[j]}
+	}
+	var min, max [2]float64
+	for k := 0; k < 2; k++ {
+		min[k], max[k] = math.Inf(1), math.Inf(-1)
+		for _, v := range polygon {
+			min[k] = math.Min(min[k], float64(v.UV[k]))
+			max[k] = math.Max(max[k], float64(v.UV[k]))
+		}
+	}
+	var first, last [2]int // ranges of the repetitions
+	for k := 0; k < 2; k++ {
+		first[k] = int(math.Floor(min[k]))
+		last[k] = int(math.Ceil(max[k])) - 1
+		if last[k] < first[k] {
+			last[k] = first[k]
+		}
+	}
+	cells := (last[0] - first[0] + 1) * (last[1] - first[1] + 1)
+
+	shift := func(piece []clipVertex, ku, kv int, clampUV bool) []clipVertex {
+		for i := range piece {
+			piece[i].UV[0] -= float32(ku)
+			piece[i].UV[1] -= float32(kv)
+			if clampUV {
+				for k := 0; k < 2; k++ {
+					piece[i].UV[k] = float32(math.Min(math.Max(float64(piece[i].UV[k]), 0), 1))
+				}
+			}
+		}
+		return piece
+	}
+
+	if clamp || cells > maxAtlasCells {
+		return [][]clipVertex{shift(polygon, first[0], first[1], true)}
+	}
+	if cells == 1 {
+		return [][]clipVertex{shift(polygon, first[0], first[1], false)}
+	}
+
+	pieces := make([][]clipVertex, 0, cells)
+	for ku := first[0]; ku <= last[0]; ku++ {
+		column := clipPolygonHalfPlane(polygon, func(v clipVertex) float32 { return v.UV[0] - float32(ku) })
+		column = clipPolygonHalfPlane(column, func(v clipVertex) float32 { return float32(ku+1) - v.UV[0] })
+		for kv := first[1]; kv <= last[1]; kv++ {
+			piece := clipPolygonHalfPlane(column, func(v clipVertex) float32 { return v.UV[1] - float32(kv) })
+			piece = clipPolygonHalfPlane(piece, func(v clipVertex) float32 { return float32(kv+1) - v.UV[1] })
+			if len(piece) >= 3 {
+				pieces = append(pieces, shift(piece, ku, kv, false))
+			}
+		}
+	}
+	return pieces
+}
+
+// AtlasTriangles returns the textured triangles of all elements of texture with UV coordinates mapped to the atlas
+// (the texture of the element i is the texture i of the atlas). The triangles using repetitions of the textures
+// (UV coordinates outside of [0,1]) are split at the borders of the repetitions or clamped if atlas.Clamp is true.
+func (texture *TextureType) AtlasTriangles(atlas *AtlasType) TexturedTrianglesType {
+	texTriangles := make(TexturedTrianglesType, 0)
+	for i, texEl := range texture.Elements {
+		for t := range texEl.TexturedTriangles {
+			for _, piece := range atlasPieces(&texEl.TexturedTriangles[t], atlas.Clamp) {
+				for j := 1; j+1 < len(piece); j++ { // triangle fan
+					texTriangles = append(texTriangles, TexturedTriangleType{
+						Triangle:   TriangleType{piece[0].Endpoint, piece[j].Endpoint, piece[j+1].Endpoint},
+						TriangleUV: TriangleUVType{atlas.MapUV(i, piece[0].UV), atlas.MapUV(i, piece[j].UV), atlas.MapUV(i, piece[j+1].UV)},
+					})
+				}
+			}
+		}
+	}
+	return texTriangles
+}
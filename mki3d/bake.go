@@ -0,0 +1,119 @@
+package mki3d
+
+/* baking of lighting and ambient occlusion into endpoint colors */
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"math"
+)
+
+// BakeOptions are the parameters of BakeLighting
+type BakeOptions struct {
+	AmbientOcclusion bool    // multiply the colors by the ambient occlusion term
+	Samples          int     // number of rays per endpoint (default 32)
+	MaxDistance      float32 // occluders farther than MaxDistance are ignored (0 means the diagonal of the bounding box)
+	Strength         float32 // the colors are multiplied by 1-Strength*occlusion (0 means 1)
+	BothSides        bool    // use the less occluded side of each triangle instead of the front (counterclockwise) side
+}
+
+// shadeFactor returns the shading factor of the triangle under light as computed in the triangle vertex shader
+func shadeFactor(triangle *TriangleType, light LightType) float32 {
+	shade := float32(math.Abs(float64(triangle.Normal().Dot(mgl32.Vec3(light.Vector)))))
+	return light.AmbientFraction + (1-light.AmbientFraction)*shade
+}
+
+// occlusion returns the fraction (in [0,1]) of the cosine-weighted rays from the endpoint j of the triangle ti
+// that hit other triangles within maxDistance. If bothSides is true, then both sides of the triangle are tested
+// and the less occluded one is used.
+func (bvh *triangleBVH) occlusion(ti, j int, samples int, maxDistance float32, bothSides bool) float32 {
+	triangle := &bvh.Triangles[ti]
+	normal := triangle.Normal()
+	if normal.Dot(normal) == 0 {
+		return 0
+	}
+	// start the rays slightly inside the triangle and off its plane
+	p := mgl32.Vec3(triangle[j].Position)
+	centroid := mgl32.Vec3(triangle[0].Position).Add(mgl32.Vec3(triangle[1].Position)).Add(mgl32.Vec3(triangle[2].Position)).Mul(1.0 / 3)
+	p = p.Add(centroid.Sub(p).Mul(1e-3))
+	epsilon := maxDistance * 1e-5
+
+	u, v := tubeFrame(normal)
+	golden := math.Pi * (3 - math.Sqrt(5))
+	sides := []float32{1}
+	if bothSides {
+		sides = append(sides, -1)
+	}
+	best := float32(1)
+	for _, side := range sides {
+		n := normal.Mul(side)
+		origin := p.Add(n.Mul(epsilon))
+		hits := 0
+		for i := 0; i < samples; i++ {
+			// Fibonacci points on the hemisphere distributed by cosine
+			r := math.Sqrt((float64(i) + 0.5) / float64(samples))
+			phi := golden * float64(i)
+			dir := u.Mul(float32(r * math.Cos(phi))).Add(v.Mul(float32(r * math.Sin(phi)))).Add(n.Mul(float32(math.Sqrt(1 - r*r))))
+			if bvh.Occluded(origin, dir, maxDistance, ti) {
+				hits++
+			}
+		}
+		if occlusion := float32(hits) / float32(samples); occlusion < best {
+			best = occlusion
+		}
+	}
+	return best
+}
+
+// BakeLighting returns a pointer to the copy of mki3dData with the shading of mki3dData.Light (computed as in the
+// triangle shader with identity model matrix) and optionally ambient occlusion baked into the colors of the endpoints
+// of the triangles of the model. The light of the copy has AmbientFraction = 1, so its triangles are rendered with the
+// same colors without lighting.
+// The textured elements are excluded from baking: the texture shader does not use endpoint colors, so their shading
+// can not be baked and they are rendered unlit (with the plain texture colors) in the copy. They are only used as
+// occluders for the ambient occlusion.
+func (mki3dData *Mki3dType) BakeLighting(options BakeOptions) *Mki3dType {
+	triangles := mki3dData.Model.Triangles
+	baked := make(TrianglesType, len(triangles))
+	copy(baked, triangles)
+
+	var bvh *triangleBVH
+	samples, maxDistance, strength := options.Samples, options.MaxDistance, options.Strength
+	if options.AmbientOcclusion {
+		occluders := make(TrianglesType, 0, len(triangles))
+		occluders = append(occluders, triangles...)
+		if mki3dData.Texture != nil {
+			for _, texEl := range mki3dData.Texture.Elements {
+				occluders = append(occluders, texEl.TexturedTriangles.GetTriangles()...)
+			}
+		}
+		bvh = makeTriangleBVH(occluders)
+		if samples <= 0 {
+			samples = 32
+		}
+		if maxDistance <= 0 {
+			box := mki3dData.BoundingBox()
+			maxDistance = mgl32.Vec3(box.Max).Sub(mgl32.Vec3(box.Min)).Len()
+		}
+		if strength == 0 {
+			strength = 1
+		}
+	}
+
+	for i := range baked {
+		shade := shadeFactor(&triangles[i], mki3dData.Light)
+		for j := 0; j < 3; j++ {
+			factor := shade
+			if bvh != nil && maxDistance > 0 {
+				factor *= 1 - strength*bvh.occlusion(i, j, samples, maxDistance, options.BothSides)
+			}
+			for k := 0; k < 3; k++ {
+				baked[i][j].Color[k] *= factor
+			}
+		}
+	}
+
+	result := *mki3dData
+	result.Model = ModelType{Segments: mki3dData.Model.Segments, Triangles: baked}
+	result.Light.AmbientFraction = 1
+	return &result
+}
@@ -0,0 +1,61 @@
+package mki3d
+
+import (
+	"math"
+	"testing"
+)
+
+// shaderColor returns color shaded as in the triangle vertex shader: (ambient+(1-ambient)*|normal.light|)*color
+func shaderColor(color, normal Vector3dType, light LightType) Vector3dType {
+	shade := math.Abs(float64(normal[0]*light.Vector[0] + normal[1]*light.Vector[1] + normal[2]*light.Vector[2]))
+	factor := float32(float64(light.AmbientFraction) + (1-float64(light.AmbientFraction))*shade)
+	return Vector3dType{factor * color[0], factor * color[1], factor * color[2]}
+}
+
+func TestBakeLighting(t *testing.T) {
+	color := Vector3dType{1, 0.5, 0.2}
+	endpoint := func(x, y, z float32) EndpointType {
+		return EndpointType{Position: Vector3dType{x, y, z}, Color: color}
+	}
+	textured := TexturedTriangleType{Triangle: TriangleType{endpoint(0, 0, 0), endpoint(1, 0, 0), endpoint(0, 1, 0)}}
+	m := &Mki3dType{
+		Model: ModelType{
+			Segments: SegmentsType{{endpoint(0, 0, 0), endpoint(1, 1, 1)}},
+			Triangles: TrianglesType{
+				{endpoint(0, 0, 0), endpoint(1, 0, 0), endpoint(0, 1, 0)}, // normal (0,0,1)
+				{endpoint(0, 0, 0), endpoint(0, 0, 1), endpoint(1, 0, 0)}, // normal (0,1,0)
+			},
+		},
+		Light:   LightType{Vector: Vector3dType{0, 0.6, -0.8}, AmbientFraction: 0.25},
+		Texture: &TextureType{Elements: []TextureElementType{{TexturedTriangles: TexturedTrianglesType{textured}}}},
+	}
+	normals := []Vector3dType{{0, 0, 1}, {0, 1, 0}}
+
+	baked := m.BakeLighting(BakeOptions{})
+
+	if baked.Light.AmbientFraction != 1 {
+		t.Errorf("baked AmbientFraction %v, want 1", baked.Light.AmbientFraction)
+	}
+	for i, triangle := range baked.Model.Triangles {
+		want := shaderColor(color, normals[i], m.Light)
+		for j := 0; j < 3; j++ {
+			// rendered with AmbientFraction = 1 the baked color is unchanged by the shader
+			got := shaderColor(triangle[j].Color, normals[i], baked.Light)
+			for k := 0; k < 3; k++ {
+				if math.Abs(float64(got[k]-want[k])) > 1e-6 {
+					t.Errorf("triangle %d endpoint %d: baked color %v, want %v", i, j, got, want)
+					break
+				}
+			}
+		}
+	}
+	if m.Model.Triangles[0][0].Color != color {
+		t.Error("BakeLighting changed the colors of the original model")
+	}
+	if baked.Model.Segments[0][0].Color != color {
+		t.Error("BakeLighting changed the colors of the segments")
+	}
+	if baked.Texture.Elements[0].TexturedTriangles[0] != textured {
+		t.Error("BakeLighting changed the textured triangles")
+	}
+}
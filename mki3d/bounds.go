@@ -0,0 +1,83 @@
+package mki3d
+
+/* bounding boxes of MKI3D data */
+
+// BoxType is an axis-aligned box with the corners Min and Max.
+// Empty is true if the box contains no points.
+type BoxType struct {
+	Min   Vector3dType
+	Max   Vector3dType
+	Empty bool
+}
+
+// MakeEmptyBox returns the empty box
+func MakeEmptyBox() BoxType {
+	return BoxType{Empty: true}
+}
+
+// AddPoint extends box so that it contains p
+func (box *BoxType) AddPoint(p Vector3dType) {
+	if box.Empty {
+		box.Min = p
+		box.Max = p
+		box.Empty = false
+		return
+	}
+	for i := 0; i < 3; i++ {
+		if p[i] < box.Min[i] {
+			box.Min[i] = p[i]
+		}
+		if p[i] > box.Max[i] {
+			box.Max[i] = p[i]
+		}
+	}
+}
+
+// AddBox extends box so that it contains other
+func (box *BoxType) AddBox(other BoxType) {
+	if other.Empty {
+		return
+	}
+	box.AddPoint(other.Min)
+	box.AddPoint(other.Max)
+}
+
+// BoundingBox returns the box containing all endpoints of the triangles
+func (triangles TrianglesType) BoundingBox() BoxType {
+	box := MakeEmptyBox()
+	for _, triangle := range triangles {
+		for j := 0; j < 3; j++ {
+			box.AddPoint(triangle[j].Position)
+		}
+	}
+	return box
+}
+
+// BoundingBox returns the box containing all endpoints of the segments
+func (segments SegmentsType) BoundingBox() BoxType {
+	box := MakeEmptyBox()
+	for _, segment := range segments {
+		for j := 0; j < 2; j++ {
+			box.AddPoint(segment[j].Position)
+		}
+	}
+	return box
+}
+
+// BoundingBox returns the box containing all endpoints of the segments and triangles of the model
+func (model *ModelType) BoundingBox() BoxType {
+	box := model.Triangles.BoundingBox()
+	box.AddBox(model.Segments.BoundingBox())
+	return box
+}
+
+// BoundingBox returns the box containing all endpoints of the model and of the textured triangles of mki3dData
+func (mki3dData *Mki3dType) BoundingBox() BoxType {
+	box := mki3dData.Model.BoundingBox()
+	if mki3dData.Texture != nil {
+		for _, texEl := range mki3dData.Texture.Elements {
+			box.AddBox(texEl.TexturedTriangles.GetTriangles().BoundingBox())
+		}
+	}
+	return box
+}
@@ -0,0 +1,177 @@
+package mki3d
+
+/* clipping of geometry by the axis-aligned box of MKI3D editor */
+
+// clipVertex is an endpoint with its UV coordinates (used by clipping of textured triangles)
+type clipVertex struct {
+	Endpoint EndpointType
+	UV       Vector2dType
+}
+
+// interpolate returns a+t*(b-a)
+func interpolate(a, b Vector3dType, t float32) Vector3dType {
+	return Vector3dType{
+		a[0] + t*(b[0]-a[0]),
+		a[1] + t*(b[1]-a[1]),
+		a[2] + t*(b[2]-a[2]),
+	}
+}
+
+// interpolateUV returns a+t*(b-a) for UV coordinates
+func interpolateUV(a, b Vector2dType, t float32) Vector2dType {
+	return Vector2dType{
+		a[0] + t*(b[0]-a[0]),
+		a[1] + t*(b[1]-a[1]),
+	}
+}
+
+// interpolateVertex returns the vertex between a and b with position, color and UV interpolated.
+// The set index is taken from a.
+func interpolateVertex(a, b clipVertex, t float32) clipVertex {
+	return clipVertex{
+		Endpoint: EndpointType{
+			Position: interpolate(a.Endpoint.Position, b.Endpoint.Position, t),
+			Color:    interpolate(a.Endpoint.Color, b.Endpoint.Color, t),
+			Set:      a.Endpoint.Set,
+		},
+		UV: interpolateUV(a.UV, b.UV, t),
+	}
+}
+
+// clipPlaneDistance returns the signed distance of p from the clipping plane
+// number plane (0..5) of the box [min,max]. The distance is not negative inside the box.
+func clipPlaneDistance(p Vector3dType, plane int, min, max Vector3dType) float32 {
+	axis := plane / 2
+	if plane%2 == 0 {
+		return p[axis] - min[axis]
+	}
+	return max[axis] - p[axis]
+}
+
+// clipPolygon clips the convex polygon by the box [min,max] (Sutherland-Hodgman algorithm).
+func clipPolygon(polygon []clipVertex, min, max Vector3dType) []clipVertex {
+	for plane := 0; plane < 6 && len(polygon) > 0; plane++ {
+		output := make([]clipVertex, 0, len(polygon)+1)
+		for i := range polygon {
+			a := polygon[i]
+			b := polygon[(i+1)%len(polygon)]
+			da := clipPlaneDistance(a.Endpoint.Position, plane, min, max)
+			db := clipPlaneDistance(b.Endpoint.Position, plane, min, max)
+			if da >= 0 {
+				output = append(output, a)
+			}
+			if (da >= 0) != (db >= 0) {
+				// the edge crosses the plane - the new vertex takes the set of the inner endpoint
+				if da >= 0 {
+					output = append(output, interpolateVertex(a, b, da/(da-db)))
+				} else {
+					output = append(output, interpolateVertex(b, a, db/(db-da)))
+				}
+			}
+		}
+		polygon = output
+	}
+	return polygon
+}
+
+// Clip returns the new sequence of triangles obtained by clipping triangles with the box [min,max].
+// The triangles crossing the box faces are cut and their colors are interpolated.
+func (triangles TrianglesType) Clip(min, max Vector3dType) TrianglesType {
+	clipped := make([]TriangleType, 0, len(triangles))
+	for _, triangle := range triangles {
+		polygon := []clipVertex{{Endpoint: triangle[0]}, {Endpoint: triangle[1]}, {Endpoint: triangle[2]}}
+		polygon = clipPolygon(polygon, min, max)
+		for i := 2; i < len(polygon); i++ { // triangle fan of the convex polygon
+			clipped = append(clipped, TriangleType{polygon[0].Endpoint, polygon[i-1].Endpoint, polygon[i].Endpoint})
+		}
+	}
+	return TrianglesType(clipped)
+}
+
+// Clip returns the new sequence of textured triangles obtained by clipping texTriangles with the box [min,max].
+// The triangles crossing the box faces are cut and their colors and UV coordinates are interpolated.
+func (texTriangles TexturedTrianglesType) Clip(min, max Vector3dType) TexturedTrianglesType {
+	clipped := make([]TexturedTriangleType, 0, len(texTriangles))
+	for _, texTriangle := range texTriangles {
+		polygon := make([]clipVertex, 3)
+		for j := 0; j < 3; j++ {
+			polygon[j] = clipVertex{Endpoint: texTriangle.Triangle[j], UV: texTriangle.TriangleUV[j]}
+		}
+		polygon = clipPolygon(polygon, min, max)
+		for i := 2; i < len(polygon); i++ { // triangle fan of the convex polygon
+			clipped = append(clipped, TexturedTriangleType{
+				Triangle:   TriangleType{polygon[0].Endpoint, polygon[i-1].Endpoint, polygon[i].Endpoint},
+				TriangleUV: TriangleUVType{polygon[0].UV, polygon[i-1].UV, polygon[i].UV},
+			})
+		}
+	}
+	return TexturedTrianglesType(clipped)
+}
+
+// Clip returns the new sequence of segments obtained by clipping segments with the box [min,max]
+// (Liang-Barsky algorithm). The colors of the cut segments are interpolated.
+func (segments SegmentsType) Clip(min, max Vector3dType) SegmentsType {
+	clipped := make([]SegmentType, 0, len(segments))
+	for _, segment := range segments {
+		t0, t1 := float32(0), float32(1)
+		for plane := 0; plane < 6 && t0 <= t1; plane++ {
+			d0 := clipPlaneDistance(segment[0].Position, plane, min, max)
+			d1 := clipPlaneDistance(segment[1].Position, plane, min, max)
+			switch {
+			case d0 < 0 && d1 < 0:
+				t0, t1 = 1, 0 // the segment is outside
+			case d0 < 0:
+				if t := d0 / (d0 - d1); t > t0 {
+					t0 = t
+				}
+			case d1 < 0:
+				if t := d0 / (d0 - d1); t < t1 {
+					t1 = t
+				}
+			}
+		}
+		if t0 > t1 {
+			continue
+		}
+		a := clipVertex{Endpoint: segment[0]}
+		b := clipVertex{Endpoint: segment[1]}
+		clipped = append(clipped, SegmentType{
+			interpolateVertex(a, b, t0).Endpoint,
+			interpolateVertex(b, a, 1-t1).Endpoint,
+		})
+	}
+	return SegmentsType(clipped)
+}
+
+// Clip returns the new model obtained by clipping segments and triangles of the model with the box [min,max].
+func (model *ModelType) Clip(min, max Vector3dType) ModelType {
+	return ModelType{
+		Segments:  model.Segments.Clip(min, max),
+		Triangles: model.Triangles.Clip(min, max),
+	}
+}
+
+// Clip returns the new TextureType with the textured triangles of all elements clipped with the box [min,max].
+// The texture definitions are copied.
+func (texture *TextureType) Clip(min, max Vector3dType) *TextureType {
+	elements := make([]TextureElementType, 0, len(texture.Elements))
+	for _, texEl := range texture.Elements {
+		elements = append(elements, TextureElementType{
+			Def:               texEl.Def,
+			TexturedTriangles: texEl.TexturedTriangles.Clip(min, max),
+		})
+	}
+	return &TextureType{Elements: TextureElementsType(elements), Index: texture.Index}
+}
+
+// Clip returns a pointer to the copy of mki3dData with the model and the textured triangles
+// clipped with the box [mki3dData.ClipMinVector, mki3dData.ClipMaxVector],
+// so that the result matches the clipped view of MKI3D editor.
+func (mki3dData *Mki3dType) Clip() *Mki3dType {
+	clipped := *mki3dData
+	clipped.Model = mki3dData.Model.Clip(mki3dData.ClipMinVector, mki3dData.ClipMaxVector)
+	if mki3dData.Texture != nil {
+		clipped.Texture = mki3dData.Texture.Clip(mki3dData.ClipMinVector, mki3dData.ClipMaxVector)
+	}
+	return &clipped
+}
@@ -0,0 +1,65 @@
+package mki3d
+
+/* segments representing the cursor and the markers */
+
+// GetCrossSegments returns three axis-aligned segments crossing at cursor.Position.
+// The half-length of each segment is cursor.Step.
+func (cursor *CursorType) GetCrossSegments() SegmentsType {
+	segments := make([]SegmentType, 0, 3)
+	for axis := 0; axis < 3; axis++ {
+		a := cursor.Position
+		b := cursor.Position
+		a[axis] -= cursor.Step
+		b[axis] += cursor.Step
+		segments = append(segments, SegmentType{
+			{Position: a, Color: cursor.Color},
+			{Position: b, Color: cursor.Color},
+		})
+	}
+	return SegmentsType(segments)
+}
+
+// GetMarkerSegments returns the edges of a small octahedron with the center at the marker position
+// and the color of the marker. The size of the octahedron is proportional to step.
+func GetMarkerSegments(marker *EndpointType, step float32) SegmentsType {
+	size := step / 2
+	var vertices [6]Vector3dType // vertices of the octahedron: -X,+X,-Y,+Y,-Z,+Z
+	for i := range vertices {
+		vertices[i] = marker.Position
+		if i%2 == 0 {
+			vertices[i][i/2] -= size
+		} else {
+			vertices[i][i/2] += size
+		}
+	}
+	segments := make([]SegmentType, 0, 12)
+	for i := 0; i < 6; i++ {
+		for j := i + 1; j < 6; j++ {
+			if i/2 == j/2 {
+				continue // opposite vertices
+			}
+			segments = append(segments, SegmentType{
+				{Position: vertices[i], Color: marker.Color, Set: marker.Set},
+				{Position: vertices[j], Color: marker.Color, Set: marker.Set},
+			})
+		}
+	}
+	return SegmentsType(segments)
+}
+
+// GetSegments returns the segments of the cross-hair of the cursor,
+// the glyphs of the markers (if they are not nil)
+// and the segment between the markers (if both markers are not nil).
+func (cursor *CursorType) GetSegments() SegmentsType {
+	segments := cursor.GetCrossSegments()
+	if cursor.Marker1 != nil {
+		segments = append(segments, GetMarkerSegments(cursor.Marker1, cursor.Step)...)
+	}
+	if cursor.Marker2 != nil {
+		segments = append(segments, GetMarkerSegments(cursor.Marker2, cursor.Step)...)
+	}
+	if cursor.Marker1 != nil && cursor.Marker2 != nil {
+		segments = append(segments, SegmentType{*cursor.Marker1, *cursor.Marker2})
+	}
+	return segments
+}
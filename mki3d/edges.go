@@ -0,0 +1,147 @@
+package mki3d
+
+/* extraction of edges of triangles as segments */
+
+import (
+	"math"
+)
+
+// EdgeColorMode decides the colors of the extracted edges
+type EdgeColorMode int
+
+const (
+	EdgeColorInherit EdgeColorMode = iota // the colors of the endpoints of the triangle
+	EdgeColorFixed                        // the color EdgeOptions.Color
+	EdgeColorDarken                       // the colors of the endpoints of the triangle multiplied by EdgeOptions.Darken
+)
+
+// EdgeOptions are the parameters of edge extraction
+type EdgeOptions struct {
+	ColorMode EdgeColorMode
+	Color     Vector3dType // color used in EdgeColorFixed mode
+	Darken    float32      // factor used in EdgeColorDarken mode (e.g. 0.5)
+}
+
+// meshEdge is an edge of triangles welded by positions
+type meshEdge struct {
+	Segment   SegmentType // the edge with the endpoints of the first triangle containing it
+	Triangles []int       // triangles containing the edge
+}
+
+// meshEdges returns the unique edges of the triangles (in the order of appearance)
+func (triangles TrianglesType) meshEdges() []*meshEdge {
+	edges := make([]*meshEdge, 0)
+	index := make(map[[2]Vector3dType]*meshEdge)
+	for ti, triangle := range triangles {
+		for j := 0; j < 3; j++ {
+			a, b := triangle[j], triangle[(j+1)%3]
+			if a.Position == b.Position {
+				continue // degenerated edge
+			}
+			key := [2]Vector3dType{a.Position, b.Position}
+			if lessVector(b.Position, a.Position) {
+				key = [2]Vector3dType{b.Position, a.Position}
+			}
+			e, ok := index[key]
+			if !ok {
+				e = &meshEdge{Segment: SegmentType{a, b}}
+				index[key] = e
+				edges = append(edges, e)
+			}
+			e.Triangles = append(e.Triangles, ti)
+		}
+	}
+	return edges
+}
+
+// lessVector compares vectors lexicographically
+func lessVector(a, b Vector3dType) bool {
+	for i := 0; i < 3; i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// edgeSegment returns the segment of the edge with the colors set according to options
+func edgeSegment(segment SegmentType, options EdgeOptions) SegmentType {
+	for j := 0; j < 2; j++ {
+		switch options.ColorMode {
+		case EdgeColorFixed:
+			segment[j].Color = options.Color
+		case EdgeColorDarken:
+			for k := 0; k < 3; k++ {
+				segment[j].Color[k] *= options.Darken
+			}
+		}
+	}
+	return segment
+}
+
+// selectEdges returns the segments of the edges accepted by selected
+func (triangles TrianglesType) selectEdges(options EdgeOptions, selected func(e *meshEdge) bool) SegmentsType {
+	segments := make(SegmentsType, 0)
+	for _, e := range triangles.meshEdges() {
+		if selected(e) {
+			segments = append(segments, edgeSegment(e.Segment, options))
+		}
+	}
+	return segments
+}
+
+// WireframeEdges returns the segments of all unique edges of the triangles.
+// The endpoints of the segments have the sets of the endpoints of the first triangle containing the edge.
+func (triangles TrianglesType) WireframeEdges(options EdgeOptions) SegmentsType {
+	return triangles.selectEdges(options, func(e *meshEdge) bool {
+		return true
+	})
+}
+
+// BoundaryEdges returns the segments of the edges contained in exactly one triangle.
+func (triangles TrianglesType) BoundaryEdges(options EdgeOptions) SegmentsType {
+	return triangles.selectEdges(options, func(e *meshEdge) bool {
+		return len(e.Triangles) == 1
+	})
+}
+
+// FeatureEdges returns the segments of the edges where the angle between the normals of the two adjacent
+// triangles exceeds angle (in radians). The boundary edges and the edges shared by more than two
+// triangles are also returned. The orientation of the triangles need not be consistent.
+func (triangles TrianglesType) FeatureEdges(angle float32, options EdgeOptions) SegmentsType {
+	threshold := float32(math.Cos(float64(angle)))
+	return triangles.selectEdges(options, func(e *meshEdge) bool {
+		if len(e.Triangles) != 2 {
+			return true
+		}
+		t0, t1 := &triangles[e.Triangles[0]], &triangles[e.Triangles[1]]
+		n0, n1 := t0.Normal(), t1.Normal()
+		if sameEdgeDirection(t0, t1, e.Segment) {
+			n1 = n1.Mul(-1) // make orientations consistent
+		}
+		return n0.Dot(n1) < threshold
+	})
+}
+
+// sameEdgeDirection returns true if the edge is traversed in the same direction in both triangles
+// (i.e. the triangles have inconsistent orientations)
+func sameEdgeDirection(t0, t1 *TriangleType, edge SegmentType) bool {
+	forward := func(t *TriangleType) bool {
+		for j := 0; j < 3; j++ {
+			if t[j].Position == edge[0].Position {
+				return t[(j+1)%3].Position == edge[1].Position
+			}
+		}
+		return false
+	}
+	return forward(t0) == forward(t1)
+}
+
+// WireframeModel returns the model with the segments of the wireframe edges of the triangles of model
+// appended to the segments of model and without triangles.
+func (model *ModelType) WireframeModel(options EdgeOptions) ModelType {
+	segments := make(SegmentsType, 0, len(model.Segments))
+	segments = append(segments, model.Segments...)
+	segments = append(segments, model.Triangles.WireframeEdges(options)...)
+	return ModelType{Segments: segments, Triangles: TrianglesType{}}
+}
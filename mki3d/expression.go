@@ -0,0 +1,418 @@
+package mki3d
+
+/* safe evaluator of arithmetic expressions in GLSL-like syntax (as used in Texturion definitions) */
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ExpressionFunctions are additional functions that can be called in expressions
+type ExpressionFunctions map[string]func(args ...float64) float64
+
+// ExpressionType is a parsed expression that can be evaluated for the values of its variables.
+// Boolean values are represented as 1 (true) and 0 (false).
+type ExpressionType struct {
+	Source    string
+	Variables []string
+	root      exprNode
+}
+
+// exprNode is a node of the syntax tree of an expression
+type exprNode func(values []float64) float64
+
+// maxExpressionDepth limits the nesting of expressions
+const maxExpressionDepth = 200
+
+// builtinFunctions are GLSL functions of float arguments available in expressions
+var builtinFunctions = map[string]func(args ...float64) float64{
+	"sin":         func(a ...float64) float64 { return math.Sin(a[0]) },
+	"cos":         func(a ...float64) float64 { return math.Cos(a[0]) },
+	"tan":         func(a ...float64) float64 { return math.Tan(a[0]) },
+	"asin":        func(a ...float64) float64 { return math.Asin(a[0]) },
+	"acos":        func(a ...float64) float64 { return math.Acos(a[0]) },
+	"atan":        atanFunction,
+	"sinh":        func(a ...float64) float64 { return math.Sinh(a[0]) },
+	"cosh":        func(a ...float64) float64 { return math.Cosh(a[0]) },
+	"tanh":        func(a ...float64) float64 { return math.Tanh(a[0]) },
+	"pow":         func(a ...float64) float64 { return math.Pow(a[0], a[1]) },
+	"exp":         func(a ...float64) float64 { return math.Exp(a[0]) },
+	"log":         func(a ...float64) float64 { return math.Log(a[0]) },
+	"exp2":        func(a ...float64) float64 { return math.Exp2(a[0]) },
+	"log2":        func(a ...float64) float64 { return math.Log2(a[0]) },
+	"sqrt":        func(a ...float64) float64 { return math.Sqrt(a[0]) },
+	"inversesqrt": func(a ...float64) float64 { return 1 / math.Sqrt(a[0]) },
+	"abs":         func(a ...float64) float64 { return math.Abs(a[0]) },
+	"sign":        signFunction,
+	"floor":       func(a ...float64) float64 { return math.Floor(a[0]) },
+	"ceil":        func(a ...float64) float64 { return math.Ceil(a[0]) },
+	"round":       func(a ...float64) float64 { return math.Round(a[0]) },
+	"trunc":       func(a ...float64) float64 { return math.Trunc(a[0]) },
+	"fract":       func(a ...float64) float64 { return a[0] - math.Floor(a[0]) },
+	"mod":         func(a ...float64) float64 { return a[0] - a[1]*math.Floor(a[0]/a[1]) },
+	"min":         func(a ...float64) float64 { return math.Min(a[0], a[1]) },
+	"max":         func(a ...float64) float64 { return math.Max(a[0], a[1]) },
+	"clamp":       func(a ...float64) float64 { return math.Min(math.Max(a[0], a[1]), a[2]) },
+	"mix":         func(a ...float64) float64 { return a[0]*(1-a[2]) + a[1]*a[2] },
+	"step":        stepFunction,
+	"smoothstep":  smoothstepFunction,
+	"radians":     func(a ...float64) float64 { return a[0] * math.Pi / 180 },
+	"degrees":     func(a ...float64) float64 { return a[0] * 180 / math.Pi },
+	"float":       func(a ...float64) float64 { return a[0] },
+	"int":         func(a ...float64) float64 { return math.Trunc(a[0]) },
+}
+
+// builtinArity is the number of arguments of the builtin functions (if different from 1)
+var builtinArity = map[string]int{
+	"pow": 2, "mod": 2, "min": 2, "max": 2, "step": 2,
+	"clamp": 3, "mix": 3, "smoothstep": 3,
+}
+
+func atanFunction(a ...float64) float64 {
+	if len(a) == 2 {
+		return math.Atan2(a[0], a[1])
+	}
+	return math.Atan(a[0])
+}
+
+func signFunction(a ...float64) float64 {
+	switch {
+	case a[0] > 0:
+		return 1
+	case a[0] < 0:
+		return -1
+	}
+	return 0
+}
+
+func stepFunction(a ...float64) float64 {
+	if a[1] < a[0] {
+		return 0
+	}
+	return 1
+}
+
+func smoothstepFunction(a ...float64) float64 {
+	t := math.Min(math.Max((a[2]-a[0])/(a[1]-a[0]), 0), 1)
+	return t * t * (3 - 2*t)
+}
+
+// boolValue converts a boolean to 1 or 0
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// exprParser is a recursive descent parser of expressions
+type exprParser struct {
+	tokens    []string
+	pos       int
+	depth     int
+	variables map[string]int
+	functions ExpressionFunctions
+}
+
+// tokenizeExpression splits source into numbers, identifiers and operators
+func tokenizeExpression(source string) ([]string, error) {
+	tokens := make([]string, 0)
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			if j < len(runes) && (runes[j] == 'e' || runes[j] == 'E') {
+				k := j + 1
+				if k < len(runes) && (runes[k] == '+' || runes[k] == '-') {
+					k++
+				}
+				if k < len(runes) && unicode.IsDigit(runes[k]) {
+					for j = k; j < len(runes) && unicode.IsDigit(runes[j]); j++ {
+					}
+				}
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		default:
+			if i+1 < len(runes) {
+				two := string(runes[i : i+2])
+				switch two {
+				case "<=", ">=", "==", "!=", "&&", "||":
+					tokens = append(tokens, two)
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("+-*/()<>!?:,", r) {
+				return nil, errors.New("unexpected character '" + string(r) + "' in expression")
+			}
+			tokens = append(tokens, string(r))
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// ParseExpression parses source with the given names of variables.
+// The expressions may use numbers, the variables, the constant PI, the operators + - * / ! < <= > >= == != && || ?:
+// and calls of GLSL functions (sin, cos, pow, mix, clamp, smoothstep, ...) and of the functions from functions (may be nil).
+func ParseExpression(source string, variables []string, functions ExpressionFunctions) (*ExpressionType, error) {
+	tokens, err := tokenizeExpression(source)
+	if err != nil {
+		return nil, err
+	}
+	p := exprParser{tokens: tokens, variables: make(map[string]int), functions: functions}
+	for i, name := range variables {
+		p.variables[name] = i
+	}
+	root, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, errors.New("unexpected '" + p.tokens[p.pos] + "' in expression")
+	}
+	return &ExpressionType{Source: source, Variables: variables, root: root}, nil
+}
+
+// Eval returns the value of the expression for the values of the variables (in the order of Variables).
+// Missing values are zero.
+func (e *ExpressionType) Eval(values ...float64) float64 {
+	if len(values) < len(e.Variables) {
+		v := make([]float64, len(e.Variables))
+		copy(v, values)
+		values = v
+	}
+	return e.root(values)
+}
+
+// peek returns the current token or "" at the end
+func (p *exprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// expect consumes the token t or returns an error
+func (p *exprParser) expect(t string) error {
+	if p.peek() != t {
+		return errors.New("expected '" + t + "' in expression")
+	}
+	p.pos++
+	return nil
+}
+
+func (p *exprParser) parseTernary() (exprNode, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxExpressionDepth {
+		return nil, errors.New("expression is nested too deeply")
+	}
+	cond, err := p.parseBinary(0)
+	if err != nil || p.peek() != "?" {
+		return cond, err
+	}
+	p.pos++
+	a, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expect(":"); err != nil {
+		return nil, err
+	}
+	b, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	return func(v []float64) float64 {
+		if cond(v) != 0 {
+			return a(v)
+		}
+		return b(v)
+	}, nil
+}
+
+// binaryLevels are the binary operators from the lowest precedence
+var binaryLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/"},
+}
+
+// binaryOperator returns the node of the binary operator op
+func binaryOperator(op string, a, b exprNode) exprNode {
+	switch op {
+	case "||":
+		return func(v []float64) float64 { return boolValue(a(v) != 0 || b(v) != 0) }
+	case "&&":
+		return func(v []float64) float64 { return boolValue(a(v) != 0 && b(v) != 0) }
+	case "==":
+		return func(v []float64) float64 { return boolValue(a(v) == b(v)) }
+	case "!=":
+		return func(v []float64) float64 { return boolValue(a(v) != b(v)) }
+	case "<":
+		return func(v []float64) float64 { return boolValue(a(v) < b(v)) }
+	case "<=":
+		return func(v []float64) float64 { return boolValue(a(v) <= b(v)) }
+	case ">":
+		return func(v []float64) float64 { return boolValue(a(v) > b(v)) }
+	case ">=":
+		return func(v []float64) float64 { return boolValue(a(v) >= b(v)) }
+	case "+":
+		return func(v []float64) float64 { return a(v) + b(v) }
+	case "-":
+		return func(v []float64) float64 { return a(v) - b(v) }
+	case "*":
+		return func(v []float64) float64 { return a(v) * b(v) }
+	}
+	return func(v []float64) float64 { return a(v) / b(v) }
+}
+
+func (p *exprParser) parseBinary(level int) (exprNode, error) {
+	if level == len(binaryLevels) {
+		return p.parseUnary()
+	}
+	a, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		found := false
+		for _, o := range binaryLevels[level] {
+			found = found || o == op
+		}
+		if !found {
+			return a, nil
+		}
+		p.pos++
+		b, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		a = binaryOperator(op, a, b)
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	switch op := p.peek(); op {
+	case "-", "+", "!":
+		p.pos++
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > maxExpressionDepth {
+			return nil, errors.New("expression is nested too deeply")
+		}
+		a, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "-":
+			return func(v []float64) float64 { return -a(v) }, nil
+		case "!":
+			return func(v []float64) float64 { return boolValue(a(v) == 0) }, nil
+		}
+		return a, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	t := p.peek()
+	if t == "" {
+		return nil, errors.New("unexpected end of expression")
+	}
+	p.pos++
+	switch {
+	case t == "(":
+		a, err := p.parseTernary()
+		if err != nil {
+			return nil, err
+		}
+		return a, p.expect(")")
+	case unicode.IsDigit([]rune(t)[0]) || t[0] == '.':
+		x, err := strconv.ParseFloat(t, 64)
+		if err != nil {
+			return nil, errors.New("bad number '" + t + "' in expression")
+		}
+		return func(v []float64) float64 { return x }, nil
+	case unicode.IsLetter([]rune(t)[0]) || t[0] == '_':
+		if p.peek() == "(" {
+			return p.parseCall(t)
+		}
+		if i, ok := p.variables[t]; ok {
+			return func(v []float64) float64 { return v[i] }, nil
+		}
+		if t == "PI" {
+			return func(v []float64) float64 { return math.Pi }, nil
+		}
+		return nil, errors.New("unknown variable '" + t + "' in expression")
+	}
+	return nil, errors.New("unexpected '" + t + "' in expression")
+}
+
+// parseCall parses the arguments of the call of the function name
+func (p *exprParser) parseCall(name string) (exprNode, error) {
+	p.pos++ // "("
+	args := make([]exprNode, 0)
+	if p.peek() != ")" {
+		for {
+			a, err := p.parseTernary()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, a)
+			if p.peek() != "," {
+				break
+			}
+			p.pos++
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	f, ok := p.functions[name]
+	if !ok {
+		f, ok = builtinFunctions[name]
+		if !ok {
+			return nil, errors.New("unknown function '" + name + "' in expression")
+		}
+		arity, ok := builtinArity[name]
+		if !ok {
+			arity = 1
+		}
+		if len(args) != arity && !(name == "atan" && len(args) == 2) {
+			return nil, errors.New("wrong number of arguments of '" + name + "' in expression")
+		}
+	}
+	return func(v []float64) float64 {
+		values := make([]float64, len(args))
+		for i, a := range args {
+			values[i] = a(v)
+		}
+		return f(values...)
+	}, nil
+}
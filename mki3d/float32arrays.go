@@ -47,18 +47,25 @@ func (triangles TrianglesType) GetColorArrays() []float32 {
 	return data
 }
 
+// Normal returns the unit normal vector of the triangle
+// (or zero vector if the triangle is degenerated).
+func (triangle *TriangleType) Normal() mgl32.Vec3 {
+	a := mgl32.Vec3(triangle[0].Position)
+	b := mgl32.Vec3(triangle[1].Position)
+	c := mgl32.Vec3(triangle[2].Position)
+	normal := (b.Sub(a)).Cross(c.Sub(a))
+	if normal.Dot(normal) > 0 {
+		normal = normal.Normalize()
+	}
+	return normal
+}
+
 // Gets array which is a sequence of triangles' normal coordinates repeated for each endpoint
 func (triangles TrianglesType) GetNormalArrays() []float32 {
 	data := make([]float32, 0, 9*len(triangles)) // each triangle has 3*3 coordinates
 	for _, triangle := range triangles {
 		// compute normal
-		a := mgl32.Vec3(triangle[0].Position)
-		b := mgl32.Vec3(triangle[1].Position)
-		c := mgl32.Vec3(triangle[2].Position)
-		normal := (b.Sub(a)).Cross(c.Sub(a))
-		if normal.Dot(normal) > 0 {
-			normal = normal.Normalize()
-		}
+		normal := triangle.Normal()
 		// append to buffers
 		for j := 0; j < 3; j++ {
 			data = append(data, normal[0:3]...)
@@ -114,3 +121,38 @@ func (mki3dData *Mki3dType) GetBufferData() *BufferData {
 	return &BufferData{TrArrPtr: tPtr, SegArrPtr: sPtr}
 
 }
+
+// Numbers of float32 values per vertex in the interleaved arrays.
+const (
+	TriangleInterleavedSize = 9 // position (3), normal (3), color (3)
+	SegmentInterleavedSize  = 6 // position (3), color (3)
+	TexturedInterleavedSize = 8 // position (3), normal (3), UV (2)
+)
+
+// Gets interleaved array which is a sequence of endpoints' positions, normals and colors coordinates
+// (TriangleInterleavedSize float32 values per endpoint).
+func (triangles TrianglesType) GetInterleavedArray() []float32 {
+	data := make([]float32, 0, 3*TriangleInterleavedSize*len(triangles))
+	for _, triangle := range triangles {
+		normal := triangle.Normal()
+		for j := 0; j < 3; j++ {
+			data = append(data, triangle[j].Position[0:3]...)
+			data = append(data, normal[0:3]...)
+			data = append(data, triangle[j].Color[0:3]...)
+		}
+	}
+	return data
+}
+
+// Gets interleaved array which is a sequence of endpoints' positions and colors coordinates
+// (SegmentInterleavedSize float32 values per endpoint).
+func (segments SegmentsType) GetInterleavedArray() []float32 {
+	data := make([]float32, 0, 2*SegmentInterleavedSize*len(segments))
+	for _, segment := range segments {
+		for j := 0; j < 2; j++ {
+			data = append(data, segment[j].Position[0:3]...)
+			data = append(data, segment[j].Color[0:3]...)
+		}
+	}
+	return data
+}
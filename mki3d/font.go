@@ -0,0 +1,140 @@
+package mki3d
+
+/* built-in stroke font */
+
+// strokeFont contains glyphs of printable ASCII characters drawn with polylines on a grid
+// with x in 0..4 and y in 0..9 (baseline at y=2, capital height at y=8, descenders down to y=0).
+// Each polyline is a sequence of points "xy" and the polylines are separated by spaces.
+var strokeFont = map[rune]string{
+	' ':  "",
+	'!':  "2824 2322",
+	'"':  "1817 3837",
+	'#':  "1317 3337 0444 0646",
+	'$':  "473818070615354443321203 2129",
+	'%':  "0248 0708 4243",
+	'&':  "42060718270403123244",
+	'\'': "2827",
+	'(':  "38272332",
+	')':  "18272312",
+	'*':  "2428 0537 0735",
+	'+':  "2327 0545",
+	',':  "232211",
+	'-':  "0545",
+	'.':  "2322",
+	'/':  "0248",
+	'0':  "120307183847433212 0347",
+	'1':  "1728 2822 1232",
+	'2':  "07183847460242",
+	'3':  "07183847463515 354443321203",
+	'4':  "32380444",
+	'5':  "480805354443321203",
+	'6':  "473818070312324344351504",
+	'7':  "084812",
+	'8':  "150607183847463515 1504031232434435",
+	'9':  "031232434738180706153546",
+	':':  "2625 2322",
+	';':  "2625 232211",
+	'<':  "470543",
+	'=':  "0444 0646",
+	'>':  "074503",
+	'?':  "07183847462524 2322",
+	'@':  "34141636334347381807031232",
+	'A':  "022842 1535",
+	'B':  "0208 083847463505 3544433202",
+	'C':  "4738180703123243",
+	'D':  "02082846442202",
+	'E':  "48080242 0535",
+	'F':  "480802 0535",
+	'G':  "47381807031232434525",
+	'H':  "0208 4842 0545",
+	'I':  "1838 2822 1232",
+	'J':  "4843321203",
+	'K':  "0208 4804 2542",
+	'L':  "080242",
+	'M':  "0208244842",
+	'N':  "02084842",
+	'O':  "120307183847433212",
+	'P':  "02083847463505",
+	'Q':  "120307183847433212 2442",
+	'R':  "02083847463505 2542",
+	'S':  "473818070615354443321203",
+	'T':  "0848 2822",
+	'U':  "080312324348",
+	'V':  "082248",
+	'W':  "0812253248",
+	'X':  "0248 0842",
+	'Y':  "0825 4825 2522",
+	'Z':  "08480242",
+	'[':  "38282232",
+	'\\': "0842",
+	']':  "18282212",
+	'^':  "162836",
+	'_':  "0141",
+	'`':  "1827",
+	'a':  "06364542 441403123243",
+	'b':  "0802 0516364543321203",
+	'c':  "4536160503123243",
+	'd':  "4842 4536160503123243",
+	'e':  "04444536160503123243",
+	'f':  "4738281712 0636",
+	'g':  "4536160504133344 4641301001",
+	'h':  "0802 0516364542",
+	'i':  "2622 2728",
+	'j':  "26211000 2728",
+	'k':  "0802 4604 2542",
+	'l':  "18282332",
+	'm':  "0602 05162522 25364542",
+	'n':  "0602 0516364542",
+	'o':  "120305163645433212",
+	'p':  "0600 0516364543321203",
+	'q':  "4640 4536160503123243",
+	'r':  "0602 042646",
+	's':  "45361605143443321203",
+	't':  "18132232 0636",
+	'u':  "0603123243 4642",
+	'v':  "062246",
+	'w':  "0612243246",
+	'x':  "0642 0246",
+	'y':  "0622 4610",
+	'z':  "06460242",
+	'{':  "38272615242332",
+	'|':  "2128",
+	'}':  "18272635242312",
+	'~':  "06173647",
+}
+
+// strokeFontMissing is the glyph of the characters missing in strokeFont (a box)
+const strokeFontMissing = "0208484202"
+
+const (
+	strokeFontBaseline = 2  // y of the baseline in the grid of strokeFont
+	strokeFontCap      = 6  // height of capital letters in the grid units
+	strokeFontAdvance  = 6  // distance between the origins of consecutive characters in the grid units
+	strokeFontLine     = 10 // distance between the baselines of consecutive lines in the grid units
+)
+
+// strokeGlyph returns the polylines of the glyph of r in the grid units relative to the baseline
+func strokeGlyph(r rune) [][]Vector2dType {
+	glyph, ok := strokeFont[r]
+	if !ok {
+		glyph = strokeFontMissing
+	}
+	polylines := make([][]Vector2dType, 0)
+	polyline := make([]Vector2dType, 0)
+	for i := 0; i < len(glyph); {
+		if glyph[i] == ' ' {
+			polylines = append(polylines, polyline)
+			polyline = make([]Vector2dType, 0)
+			i++
+			continue
+		}
+		x := float32(glyph[i] - '0')
+		y := float32(glyph[i+1]-'0') - strokeFontBaseline
+		polyline = append(polyline, Vector2dType{x, y})
+		i += 2
+	}
+	if len(polyline) > 0 {
+		polylines = append(polylines, polyline)
+	}
+	return polylines
+}
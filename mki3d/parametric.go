@@ -0,0 +1,148 @@
+package mki3d
+
+/* parametric surfaces and curves defined with expressions */
+
+// MaxParametricSteps is the maximal number of steps of a parametric curve and of each parameter of a parametric surface
+// (at most 2*512*512 = 524288 triangles)
+const MaxParametricSteps = 512
+
+// ParametricSurfaceDefType defines a parametric surface with expressions of the parameters u and v.
+// The color expressions may also use the coordinates x, y, z of the point of the surface.
+// Empty color expressions have the value 1.
+// USteps and VSteps are at least 1 and at most MaxParametricSteps.
+type ParametricSurfaceDefType struct {
+	X      string  `json:"X"`
+	Y      string  `json:"Y"`
+	Z      string  `json:"Z"`
+	R      string  `json:"R"`
+	G      string  `json:"G"`
+	B      string  `json:"B"`
+	UMin   float32 `json:"uMin"`
+	UMax   float32 `json:"uMax"`
+	VMin   float32 `json:"vMin"`
+	VMax   float32 `json:"vMax"`
+	USteps int     `json:"uSteps"`
+	VSteps int     `json:"vSteps"`
+	Set    int     `json:"set"`
+}
+
+// ParametricCurveDefType defines a parametric curve with expressions of the parameter t.
+// The color expressions may also use the coordinates x, y, z of the point of the curve.
+// Empty color expressions have the value 1.
+// Steps is at least 1 and at most MaxParametricSteps.
+type ParametricCurveDefType struct {
+	X     string  `json:"X"`
+	Y     string  `json:"Y"`
+	Z     string  `json:"Z"`
+	R     string  `json:"R"`
+	G     string  `json:"G"`
+	B     string  `json:"B"`
+	TMin  float32 `json:"tMin"`
+	TMax  float32 `json:"tMax"`
+	Steps int     `json:"steps"`
+	Set   int     `json:"set"`
+}
+
+// parseExpressions parses the position expressions with the parameters and the color expressions
+// with the parameters and x, y, z
+func parseExpressions(position, color [3]string, parameters []string) (pos, col [3]*ExpressionType, err error) {
+	colorVariables := append(append([]string{}, parameters...), "x", "y", "z")
+	for k := 0; k < 3; k++ {
+		pos[k], err = ParseExpression(position[k], parameters, nil)
+		if err != nil {
+			return pos, col, err
+		}
+		source := color[k]
+		if source == "" {
+			source = "1.0"
+		}
+		col[k], err = ParseExpression(source, colorVariables, nil)
+		if err != nil {
+			return pos, col, err
+		}
+	}
+	return pos, col, nil
+}
+
+// parametricSteps returns steps limited to the range [1, MaxParametricSteps]
+func parametricSteps(steps int) int {
+	if steps < 1 {
+		return 1
+	}
+	if steps > MaxParametricSteps {
+		return MaxParametricSteps
+	}
+	return steps
+}
+
+// parametricEndpoint returns the endpoint for the values of the parameters
+func parametricEndpoint(pos, col [3]*ExpressionType, set int, parameters ...float64) EndpointType {
+	e := EndpointType{Set: set}
+	values := make([]float64, 0, len(parameters)+3)
+	values = append(values, parameters...)
+	for k := 0; k < 3; k++ {
+		e.Position[k] = float32(pos[k].Eval(parameters...))
+		values = append(values, float64(e.Position[k]))
+	}
+	for k := 0; k < 3; k++ {
+		e.Color[k] = float32(col[k].Eval(values...))
+	}
+	return e
+}
+
+// MakeParametricSurfaceTextured either returns the textured triangles of the surface defined by def or an error.
+// The UV coordinates span the whole texture over the ranges of the parameters.
+func MakeParametricSurfaceTextured(def ParametricSurfaceDefType) (TexturedTrianglesType, error) {
+	pos, col, err := parseExpressions([3]string{def.X, def.Y, def.Z}, [3]string{def.R, def.G, def.B}, []string{"u", "v"})
+	if err != nil {
+		return nil, err
+	}
+	texTriangles := surfaceGrid(parametricSteps(def.USteps), parametricSteps(def.VSteps), func(s, t float64) (EndpointType, Vector2dType) {
+		uv := Vector2dType{float32(s), float32(t)}
+		u := def.UMin + uv[0]*(def.UMax-def.UMin)
+		v := def.VMin + uv[1]*(def.VMax-def.VMin)
+		return parametricEndpoint(pos, col, def.Set, float64(u), float64(v)), uv
+	})
+	return texTriangles, nil
+}
+
+// MakeParametricSurface either returns the model with the triangles of the surface defined by def or an error.
+func MakeParametricSurface(def ParametricSurfaceDefType) (ModelType, error) {
+	texTriangles, err := MakeParametricSurfaceTextured(def)
+	if err != nil {
+		return ModelType{}, err
+	}
+	return makeTrianglesModel(texTriangles), nil
+}
+
+// MakeParametricCurve either returns the segments of the curve defined by def or an error.
+func MakeParametricCurve(def ParametricCurveDefType) (SegmentsType, error) {
+	pos, col, err := parseExpressions([3]string{def.X, def.Y, def.Z}, [3]string{def.R, def.G, def.B}, []string{"t"})
+	if err != nil {
+		return nil, err
+	}
+	steps := parametricSteps(def.Steps)
+	segments := make(SegmentsType, 0, steps)
+	point := func(i int) EndpointType {
+		t := def.TMin + (def.TMax-def.TMin)*float32(i)/float32(steps)
+		return parametricEndpoint(pos, col, def.Set, float64(t))
+	}
+	previous := point(0)
+	for i := 1; i <= steps; i++ {
+		next := point(i)
+		if next.Position != previous.Position {
+			segments = append(segments, SegmentType{previous, next})
+		}
+		previous = next
+	}
+	return segments, nil
+}
+
+// MakeParametricCurveModel either returns the model with the segments of the curve defined by def or an error.
+func MakeParametricCurveModel(def ParametricCurveDefType) (ModelType, error) {
+	segments, err := MakeParametricCurve(def)
+	if err != nil {
+		return ModelType{}, err
+	}
+	return ModelType{Segments: segments, Triangles: TrianglesType{}}, nil
+}
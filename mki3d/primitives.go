@@ -0,0 +1,392 @@
+package mki3d
+
+/* generators of primitive shapes */
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"math"
+)
+
+// PrimitiveOptions are the common parameters of the generated shapes
+type PrimitiveOptions struct {
+	Color Vector3dType // color of the endpoints
+	Set   int          // set index of the endpoints
+	// number of sides around curved shapes (or subdivisions of flat shapes); 0 means default.
+	// For icospheres it is the number of subdivision levels, limited to MaxIcoSphereLevels.
+	Resolution int
+}
+
+// MaxIcoSphereLevels is the maximal number of subdivision levels of an icosphere (20*4^6 = 81920 triangles)
+const MaxIcoSphereLevels = 6
+
+// resolution returns options.Resolution or def if it is not positive
+func (options *PrimitiveOptions) resolution(def int) int {
+	if options.Resolution > 0 {
+		return options.Resolution
+	}
+	return def
+}
+
+// endpoint returns the endpoint at position p with the color and set of options
+func (options *PrimitiveOptions) endpoint(p Vector3dType) EndpointType {
+	return EndpointType{Position: p, Color: options.Color, Set: options.Set}
+}
+
+// surfaceFunction returns the position and UV coordinates of the point of a surface for the parameters u, v in [0,1]
+type surfaceFunction func(u, v float64) (Vector3dType, Vector2dType)
+
+// surface returns the textured triangles of the grid nu x nv on the parametric surface f.
+// The triangles face the direction of the cross product of the derivatives of f by u and by v.
+// Degenerated triangles (e.g. at the poles) are skipped.
+func (options *PrimitiveOptions) surface(nu, nv int, f surfaceFunction) TexturedTrianglesType {
+	return surfaceGrid(nu, nv, func(u, v float64) (EndpointType, Vector2dType) {
+		p, uv := f(u, v)
+		return options.endpoint(p), uv
+	})
+}
+
+// surfaceGrid returns the textured triangles of the grid nu x nv with the endpoints and UV coordinates given by f
+// (see surface). f is called once for each point of the grid.
+func surfaceGrid(nu, nv int, f func(u, v float64) (EndpointType, Vector2dType)) TexturedTrianglesType {
+	endpoints := make([]EndpointType, (nu+1)*(nv+1))
+	uvs := make([]Vector2dType, len(endpoints))
+	for i := 0; i <= nu; i++ {
+		for j := 0; j <= nv; j++ {
+			endpoints[i*(nv+1)+j], uvs[i*(nv+1)+j] = f(float64(i)/float64(nu), float64(j)/float64(nv))
+		}
+	}
+	point := func(i, j int) (EndpointType, Vector2dType) {
+		return endpoints[i*(nv+1)+j], uvs[i*(nv+1)+j]
+	}
+
+	texTriangles := make(TexturedTrianglesType, 0, 2*nu*nv)
+	add := func(a, b, c EndpointType, uvA, uvB, uvC Vector2dType) {
+		if a.Position == b.Position || b.Position == c.Position || c.Position == a.Position {
+			return
+		}
+		texTriangles = append(texTriangles, TexturedTriangleType{
+			Triangle:   TriangleType{a, b, c},
+			TriangleUV: TriangleUVType{uvA, uvB, uvC},
+		})
+	}
+	for i := 0; i < nu; i++ {
+		for j := 0; j < nv; j++ {
+			p00, uv00 := point(i, j)
+			p10, uv10 := point(i+1, j)
+			p01, uv01 := point(i, j+1)
+			p11, uv11 := point(i+1, j+1)
+			add(p00, p10, p11, uv00, uv10, uv11)
+			add(p00, p11, p01, uv00, uv11, uv01)
+		}
+	}
+	return texTriangles
+}
+
+// vector returns Vector3dType with float64 coordinates converted to float32.
+// Rounding errors of trigonometric functions (e.g. math.Sin(math.Pi)) are snapped to zero relative to the largest
+// coordinate, so that the points at the poles are equal for any radius.
+func vector(x, y, z float64) Vector3dType {
+	c := [3]float64{x, y, z}
+	scale := math.Max(math.Abs(x), math.Max(math.Abs(y), math.Abs(z)))
+	var v Vector3dType
+	for k := 0; k < 3; k++ {
+		if math.Abs(c[k]) > 1e-12*scale {
+			v[k] = float32(c[k])
+		}
+	}
+	return v
+}
+
+// disk returns the textured triangles of the disk with the given radius parallel to XZ plane at height y.
+// The disk faces up if up is true and down otherwise. The UV coordinates are the planar projection.
+func (options *PrimitiveOptions) disk(radius, y float64, up bool) TexturedTrianglesType {
+	sign := 1.0
+	if up {
+		sign = -1.0
+	}
+	return options.surface(options.resolution(16), 1, func(u, v float64) (Vector3dType, Vector2dType) {
+		x := radius * v * math.Sin(sign*2*math.Pi*u)
+		z := radius * v * math.Cos(sign*2*math.Pi*u)
+		return vector(x, y, z), Vector2dType{float32(0.5 + x/(2*radius)), float32(0.5 - z/(2*radius))}
+	})
+}
+
+// MakeBoxTextured returns the textured triangles of the axis-aligned box with the given size centered at the origin.
+// Each face is mapped to the whole texture.
+func MakeBoxTextured(size Vector3dType, options PrimitiveOptions) TexturedTrianglesType {
+	faces := [6][3]Vector3dType{ // normal, u direction, v direction
+		{{1, 0, 0}, {0, 0, -1}, {0, 1, 0}},
+		{{-1, 0, 0}, {0, 0, 1}, {0, 1, 0}},
+		{{0, 1, 0}, {1, 0, 0}, {0, 0, -1}},
+		{{0, -1, 0}, {1, 0, 0}, {0, 0, 1}},
+		{{0, 0, 1}, {1, 0, 0}, {0, 1, 0}},
+		{{0, 0, -1}, {-1, 0, 0}, {0, 1, 0}},
+	}
+	texTriangles := make(TexturedTrianglesType, 0, 12)
+	for _, face := range faces {
+		n, a, b := face[0], face[1], face[2]
+		texTriangles = append(texTriangles, options.surface(1, 1, func(u, v float64) (Vector3dType, Vector2dType) {
+			var p Vector3dType
+			for k := 0; k < 3; k++ {
+				p[k] = size[k] / 2 * (n[k] + float32(2*u-1)*a[k] + float32(2*v-1)*b[k])
+			}
+			return p, Vector2dType{float32(u), float32(v)}
+		})...)
+	}
+	return texTriangles
+}
+
+// MakeBox returns the model of the axis-aligned box with the given size centered at the origin.
+func MakeBox(size Vector3dType, options PrimitiveOptions) ModelType {
+	return makeTrianglesModel(MakeBoxTextured(size, options))
+}
+
+// MakeUVSphereTextured returns the textured triangles of the sphere with the given radius centered at the origin
+// with the poles on Y axis. The texture is mapped by longitude (U) and latitude (V).
+// Resolution is the number of meridians (default 16).
+func MakeUVSphereTextured(radius float32, options PrimitiveOptions) TexturedTrianglesType {
+	r := float64(radius)
+	sides := options.resolution(16)
+	bands := (sides + 1) / 2
+	return options.surface(sides, bands, func(u, v float64) (Vector3dType, Vector2dType) {
+		switch v { // the poles are exact, so their triangles are skipped as degenerated
+		case 0:
+			return Vector3dType{0, -radius, 0}, Vector2dType{float32(u), 0}
+		case 1:
+			return Vector3dType{0, radius, 0}, Vector2dType{float32(u), 1}
+		}
+		theta := math.Pi * v // from the south pole
+		phi := 2 * math.Pi * u
+		p := vector(r*math.Sin(theta)*math.Sin(phi), -r*math.Cos(theta), r*math.Sin(theta)*math.Cos(phi))
+		return p, Vector2dType{float32(u), float32(v)}
+	})
+}
+
+// MakeUVSphere returns the model of the sphere with the given radius centered at the origin with the poles on Y axis.
+func MakeUVSphere(radius float32, options PrimitiveOptions) ModelType {
+	return makeTrianglesModel(MakeUVSphereTextured(radius, options))
+}
+
+// MakeIcoSphereTextured returns the textured triangles of the icosphere with the given radius centered at the origin.
+// Resolution is the number of subdivision levels of the icosahedron (default 2, at most MaxIcoSphereLevels).
+// The texture is mapped by longitude (U) and latitude (V) as for MakeUVSphereTextured.
+func MakeIcoSphereTextured(radius float32, options PrimitiveOptions) TexturedTrianglesType {
+	t := (1 + math.Sqrt(5)) / 2
+	v := []Vector3dType{
+		vector(-1, t, 0), vector(1, t, 0), vector(-1, -t, 0), vector(1, -t, 0),
+		vector(0, -1, t), vector(0, 1, t), vector(0, -1, -t), vector(0, 1, -t),
+		vector(t, 0, -1), vector(t, 0, 1), vector(-t, 0, -1), vector(-t, 0, 1),
+	}
+	faces := [][3]int{
+		{0, 11, 5}, {0, 5, 1}, {0, 1, 7}, {0, 7, 10}, {0, 10, 11},
+		{1, 5, 9}, {5, 11, 4}, {11, 10, 2}, {10, 7, 6}, {7, 1, 8},
+		{3, 9, 4}, {3, 4, 2}, {3, 2, 6}, {3, 6, 8}, {3, 8, 9},
+		{4, 9, 5}, {2, 4, 11}, {6, 2, 10}, {8, 6, 7}, {9, 8, 1},
+	}
+	triangles := make(TrianglesType, 0, len(faces))
+	for _, f := range faces {
+		triangles = append(triangles, TriangleType{options.endpoint(v[f[0]]), options.endpoint(v[f[1]]), options.endpoint(v[f[2]])})
+	}
+	levels := options.resolution(2)
+	if levels > MaxIcoSphereLevels {
+		levels = MaxIcoSphereLevels // each level multiplies the number of triangles by 4
+	}
+	for level := 0; ; level++ {
+		for i := range triangles {
+			for j := 0; j < 3; j++ {
+				p := mgl32.Vec3(triangles[i][j].Position).Normalize().Mul(radius)
+				triangles[i][j].Position = Vector3dType(p)
+			}
+		}
+		if level == levels {
+			break
+		}
+		triangles = triangles.SubdivideMidpoint()
+	}
+
+	texTriangles := make(TexturedTrianglesType, 0, len(triangles))
+	for _, triangle := range triangles {
+		var uv TriangleUVType
+		for j := 0; j < 3; j++ {
+			p := triangle[j].Position
+			uv[j] = Vector2dType{
+				float32(math.Atan2(float64(p[0]), float64(p[2]))/(2*math.Pi) + 0.5),
+				float32(math.Acos(float64(-p[1]/radius)) / math.Pi),
+			}
+		}
+		fixUSeam(&uv)
+		texTriangles = append(texTriangles, TexturedTriangleType{Triangle: triangle, TriangleUV: uv})
+	}
+	return texTriangles
+}
+
+// fixUSeam avoids the wrap of U coordinates of the triangle crossing the seam of a cylindrical or spherical mapping
+// (U in [0,1]) by moving the small U coordinates by 1 (the texture should be repeated).
+func fixUSeam(uv *TriangleUVType) {
+	for j := 0; j < 3; j++ {
+		for k := 0; k < 3; k++ {
+			if uv[k][0]-uv[j][0] > 0.5 {
+				uv[j][0] += 1
+			}
+		}
+	}
+}
+
+// MakeIcoSphere returns the model of the icosphere with the given radius centered at the origin.
+func MakeIcoSphere(radius float32, options PrimitiveOptions) ModelType {
+	return makeTrianglesModel(MakeIcoSphereTextured(radius, options))
+}
+
+// MakeCylinderTextured returns the textured triangles of the closed cylinder with the given radius and height
+// centered at the origin with the axis on Y axis. The side is mapped to the whole texture
+// and the caps are mapped by planar projection.
+func MakeCylinderTextured(radius, height float32, options PrimitiveOptions) TexturedTrianglesType {
+	r, h := float64(radius), float64(height)
+	texTriangles := options.surface(options.resolution(16), 1, func(u, v float64) (Vector3dType, Vector2dType) {
+		return vector(r*math.Sin(2*math.Pi*u), h*(v-0.5), r*math.Cos(2*math.Pi*u)), Vector2dType{float32(u), float32(v)}
+	})
+	texTriangles = append(texTriangles, options.disk(r, h/2, true)...)
+	texTriangles = append(texTriangles, options.disk(r, -h/2, false)...)
+	return texTriangles
+}
+
+// MakeCylinder returns the model of the closed cylinder with the given radius and height
+// centered at the origin with the axis on Y axis.
+func MakeCylinder(radius, height float32, options PrimitiveOptions) ModelType {
+	return makeTrianglesModel(MakeCylinderTextured(radius, height, options))
+}
+
+// MakeConeTextured returns the textured triangles of the closed cone with the given base radius and height
+// centered at the origin with the apex on the positive Y axis.
+func MakeConeTextured(radius, height float32, options PrimitiveOptions) TexturedTrianglesType {
+	r, h := float64(radius), float64(height)
+	texTriangles := options.surface(options.resolution(16), 1, func(u, v float64) (Vector3dType, Vector2dType) {
+		return vector((1-v)*r*math.Sin(2*math.Pi*u), h*(v-0.5), (1-v)*r*math.Cos(2*math.Pi*u)), Vector2dType{float32(u), float32(v)}
+	})
+	texTriangles = append(texTriangles, options.disk(r, -h/2, false)...)
+	return texTriangles
+}
+
+// MakeCone returns the model of the closed cone with the given base radius and height
+// centered at the origin with the apex on the positive Y axis.
+func MakeCone(radius, height float32, options PrimitiveOptions) ModelType {
+	return makeTrianglesModel(MakeConeTextured(radius, height, options))
+}
+
+// MakeTorusTextured returns the textured triangles of the torus centered at the origin around Y axis
+// with the distance majorRadius from the center of the tube to the axis and the tube radius minorRadius.
+func MakeTorusTextured(majorRadius, minorRadius float32, options PrimitiveOptions) TexturedTrianglesType {
+	R, r := float64(majorRadius), float64(minorRadius)
+	sides := options.resolution(24)
+	tubeSides := (sides + 1) / 2
+	return options.surface(sides, tubeSides, func(u, v float64) (Vector3dType, Vector2dType) {
+		d := R + r*math.Cos(2*math.Pi*v)
+		return vector(d*math.Sin(2*math.Pi*u), r*math.Sin(2*math.Pi*v), d*math.Cos(2*math.Pi*u)), Vector2dType{float32(u), float32(v)}
+	})
+}
+
+// MakeTorus returns the model of the torus centered at the origin around Y axis.
+func MakeTorus(majorRadius, minorRadius float32, options PrimitiveOptions) ModelType {
+	return makeTrianglesModel(MakeTorusTextured(majorRadius, minorRadius, options))
+}
+
+// MakePlaneTextured returns the textured triangles of the rectangle in XZ plane centered at the origin
+// facing up (positive Y) with the given width (along X) and depth (along Z).
+// Resolution is the number of subdivisions along each side (default 1).
+func MakePlaneTextured(width, depth float32, options PrimitiveOptions) TexturedTrianglesType {
+	w, d := float64(width), float64(depth)
+	n := options.resolution(1)
+	return options.surface(n, n, func(u, v float64) (Vector3dType, Vector2dType) {
+		return vector(w*(u-0.5), 0, d*(0.5-v)), Vector2dType{float32(u), float32(v)}
+	})
+}
+
+// MakePlane returns the model of the rectangle in XZ plane centered at the origin facing up.
+func MakePlane(width, depth float32, options PrimitiveOptions) ModelType {
+	return makeTrianglesModel(MakePlaneTextured(width, depth, options))
+}
+
+// MakeGrid returns the model with the segments of the grid in XZ plane centered at the origin
+// with the given width (along X) and depth (along Z). Resolution is the number of cells along each side (default 10).
+func MakeGrid(width, depth float32, options PrimitiveOptions) ModelType {
+	w, d := float64(width), float64(depth)
+	n := options.resolution(10)
+	segments := make(SegmentsType, 0, 2*(n+1))
+	for i := 0; i <= n; i++ {
+		t := float64(i)/float64(n) - 0.5
+		segments = append(segments,
+			SegmentType{options.endpoint(vector(w*t, 0, -d/2)), options.endpoint(vector(w*t, 0, d/2))},
+			SegmentType{options.endpoint(vector(-w/2, 0, d*t)), options.endpoint(vector(w/2, 0, d*t))},
+		)
+	}
+	return ModelType{Segments: segments, Triangles: TrianglesType{}}
+}
+
+// MakeArrowTextured returns the textured triangles of the arrow from the point from to the point to
+// with the shaft of the given radius. The head is a cone of the double radius.
+func MakeArrowTextured(from, to Vector3dType, radius float32, options PrimitiveOptions) TexturedTrianglesType {
+	a, b := mgl32.Vec3(from), mgl32.Vec3(to)
+	length := b.Sub(a).Len()
+	if length == 0 {
+		return TexturedTrianglesType{}
+	}
+	head := 4 * radius // length of the head
+	if head > length/2 {
+		head = length / 2
+	}
+
+	shaft := MakeCylinderTextured(radius, length-head, options)
+	shaft.translate(Vector3dType{0, (length - head) / 2, 0})
+	cone := MakeConeTextured(2*radius, head, options)
+	cone.translate(Vector3dType{0, length - head/2, 0})
+	texTriangles := append(shaft, cone...)
+
+	// move Y axis to the direction of the arrow
+	d := b.Sub(a).Normalize()
+	u, v := tubeFrame(d)
+	for i := range texTriangles {
+		for j := 0; j < 3; j++ {
+			p := texTriangles[i].Triangle[j].Position
+			q := a.Add(u.Mul(p[0])).Add(d.Mul(p[1])).Sub(v.Mul(p[2]))
+			texTriangles[i].Triangle[j].Position = Vector3dType(q)
+		}
+	}
+	return texTriangles
+}
+
+// MakeArrow returns the model of the arrow from the point from to the point to with the shaft of the given radius.
+func MakeArrow(from, to Vector3dType, radius float32, options PrimitiveOptions) ModelType {
+	return makeTrianglesModel(MakeArrowTextured(from, to, radius, options))
+}
+
+// MakeAxes returns the model with the segments of the coordinate axes from the origin with the given length
+// colored red (X), green (Y) and blue (Z). The color of options is ignored.
+func MakeAxes(length float32, options PrimitiveOptions) ModelType {
+	segments := make(SegmentsType, 0, 3)
+	for axis := 0; axis < 3; axis++ {
+		var p, color Vector3dType
+		p[axis] = length
+		color[axis] = 1
+		segments = append(segments, SegmentType{
+			{Position: Vector3dType{0, 0, 0}, Color: color, Set: options.Set},
+			{Position: p, Color: color, Set: options.Set},
+		})
+	}
+	return ModelType{Segments: segments, Triangles: TrianglesType{}}
+}
+
+// translate moves the textured triangles by the vector t
+func (texTriangles TexturedTrianglesType) translate(t Vector3dType) {
+	for i := range texTriangles {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				texTriangles[i].Triangle[j].Position[k] += t[k]
+			}
+		}
+	}
+}
+
+// makeTrianglesModel returns the model with the triangles of texTriangles and no segments
+func makeTrianglesModel(texTriangles TexturedTrianglesType) ModelType {
+	return ModelType{Segments: SegmentsType{}, Triangles: texTriangles.GetTriangles()}
+}
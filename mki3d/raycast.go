@@ -0,0 +1,142 @@
+package mki3d
+
+/* ray casting against triangles */
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"sort"
+)
+
+// bvhNode is a node of the bounding volume hierarchy of triangles
+type bvhNode struct {
+	Box         BoxType
+	Left, Right *bvhNode
+	Triangles   []int // indices of triangles in a leaf
+}
+
+// triangleBVH is a bounding volume hierarchy for casting rays against triangles
+type triangleBVH struct {
+	Triangles TrianglesType
+	Root      *bvhNode
+}
+
+// bvhLeafSize is the maximal number of triangles in a leaf
+const bvhLeafSize = 4
+
+// makeTriangleBVH returns the hierarchy of the triangles
+func makeTriangleBVH(triangles TrianglesType) *triangleBVH {
+	indices := make([]int, len(triangles))
+	for i := range indices {
+		indices[i] = i
+	}
+	bvh := triangleBVH{Triangles: triangles}
+	bvh.Root = bvh.build(indices)
+	return &bvh
+}
+
+// build returns the node containing the triangles with the indices
+func (bvh *triangleBVH) build(indices []int) *bvhNode {
+	node := bvhNode{Box: MakeEmptyBox()}
+	for _, i := range indices {
+		node.Box.AddBox(TrianglesType{bvh.Triangles[i]}.BoundingBox())
+	}
+	if len(indices) <= bvhLeafSize {
+		node.Triangles = indices
+		return &node
+	}
+	// split at the median along the longest axis of the box
+	axis := 0
+	for k := 1; k < 3; k++ {
+		if node.Box.Max[k]-node.Box.Min[k] > node.Box.Max[axis]-node.Box.Min[axis] {
+			axis = k
+		}
+	}
+	centroid := func(i int) float32 {
+		t := bvh.Triangles[i]
+		return t[0].Position[axis] + t[1].Position[axis] + t[2].Position[axis]
+	}
+	sort.Slice(indices, func(a, b int) bool { return centroid(indices[a]) < centroid(indices[b]) })
+	middle := len(indices) / 2
+	node.Left = bvh.build(indices[:middle])
+	node.Right = bvh.build(indices[middle:])
+	return &node
+}
+
+// rayHitsBox returns true if the ray origin+t*dir hits box for some t in [0, maxT]
+func rayHitsBox(origin, dir mgl32.Vec3, box BoxType, maxT float32) bool {
+	tMin, tMax := float32(0), maxT
+	for k := 0; k < 3; k++ {
+		if dir[k] == 0 {
+			if origin[k] < box.Min[k] || origin[k] > box.Max[k] {
+				return false
+			}
+			continue
+		}
+		t1 := (box.Min[k] - origin[k]) / dir[k]
+		t2 := (box.Max[k] - origin[k]) / dir[k]
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		if t1 > tMin {
+			tMin = t1
+		}
+		if t2 < tMax {
+			tMax = t2
+		}
+		if tMin > tMax {
+			return false
+		}
+	}
+	return true
+}
+
+// rayTriangle returns the distance t > 0 along the ray origin+t*dir to the triangle and true,
+// or false if the ray misses it (Moller-Trumbore algorithm)
+func rayTriangle(origin, dir mgl32.Vec3, triangle *TriangleType) (float32, bool) {
+	const epsilon = 1e-9
+	a := mgl32.Vec3(triangle[0].Position)
+	e1 := mgl32.Vec3(triangle[1].Position).Sub(a)
+	e2 := mgl32.Vec3(triangle[2].Position).Sub(a)
+	p := dir.Cross(e2)
+	det := e1.Dot(p)
+	if det > -epsilon && det < epsilon {
+		return 0, false
+	}
+	s := origin.Sub(a)
+	u := s.Dot(p) / det
+	if u < 0 || u > 1 {
+		return 0, false
+	}
+	q := s.Cross(e1)
+	v := dir.Dot(q) / det
+	if v < 0 || u+v > 1 {
+		return 0, false
+	}
+	t := e2.Dot(q) / det
+	return t, t > 0
+}
+
+// Occluded returns true if the ray origin+t*dir hits any triangle other than skip for t in (0, maxT].
+func (bvh *triangleBVH) Occluded(origin, dir mgl32.Vec3, maxT float32, skip int) bool {
+	stack := []*bvhNode{bvh.Root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if node.Box.Empty || !rayHitsBox(origin, dir, node.Box, maxT) {
+			continue
+		}
+		if node.Left == nil {
+			for _, i := range node.Triangles {
+				if i == skip {
+					continue
+				}
+				if t, hit := rayTriangle(origin, dir, &bvh.Triangles[i]); hit && t <= maxT {
+					return true
+				}
+			}
+			continue
+		}
+		stack = append(stack, node.Left, node.Right)
+	}
+	return false
+}
@@ -0,0 +1,486 @@
+package mki3d
+
+/* mesh simplification by quadric-error-metric edge collapses */
+
+import (
+	"container/heap"
+	"math"
+)
+
+// SimplifyOptions are the parameters of simplification of triangles.
+type SimplifyOptions struct {
+	TargetTriangles int     // stop when the number of triangles is not greater than TargetTriangles (if > 0)
+	TargetRatio     float32 // stop when the fraction of remaining triangles is not greater than TargetRatio (if TargetTriangles == 0)
+	MaxError        float32 // do not collapse edges with quadric error greater than MaxError (if > 0)
+}
+
+// target returns the number of triangles to be reached for n input triangles
+func (options SimplifyOptions) target(n int) int {
+	if options.TargetTriangles > 0 {
+		return options.TargetTriangles
+	}
+	if options.TargetRatio > 0 {
+		return int(math.Ceil(float64(options.TargetRatio) * float64(n)))
+	}
+	return 0
+}
+
+// quadric is a symmetric 4x4 matrix of the quadric error metric stored as
+// a2, ab, ac, ad, b2, bc, bd, c2, cd, d2 for plane (a,b,c,d)
+type quadric [10]float64
+
+// planeQuadric returns the quadric of the plane a*x+b*y+c*z+d=0 multiplied by weight
+func planeQuadric(a, b, c, d, weight float64) quadric {
+	return quadric{
+		weight * a * a, weight * a * b, weight * a * c, weight * a * d,
+		weight * b * b, weight * b * c, weight * b * d,
+		weight * c * c, weight * c * d,
+		weight * d * d,
+	}
+}
+
+func (q quadric) add(r quadric) quadric {
+	for i := range q {
+		q[i] += r[i]
+	}
+	return q
+}
+
+// error returns the value of the quadric q at point p
+func (q quadric) error(p Vector3dType) float64 {
+	x, y, z := float64(p[0]), float64(p[1]), float64(p[2])
+	return q[0]*x*x + 2*q[1]*x*y + 2*q[2]*x*z + 2*q[3]*x +
+		q[4]*y*y + 2*q[5]*y*z + 2*q[6]*y +
+		q[7]*z*z + 2*q[8]*z +
+		q[9]
+}
+
+// minimum returns the point minimising q and true, or false if the system is singular
+func (q quadric) minimum() (Vector3dType, bool) {
+	// solve [q0 q1 q2; q1 q4 q5; q2 q5 q7] * p = -[q3 q6 q8] (Cramer's rule)
+	a00, a01, a02 := q[0], q[1], q[2]
+	a11, a12, a22 := q[4], q[5], q[7]
+	b0, b1, b2 := -q[3], -q[6], -q[8]
+	det := a00*(a11*a22-a12*a12) - a01*(a01*a22-a12*a02) + a02*(a01*a12-a11*a02)
+	if math.Abs(det) < 1e-12 {
+		return Vector3dType{}, false
+	}
+	x := (b0*(a11*a22-a12*a12) - a01*(b1*a22-a12*b2) + a02*(b1*a12-a11*b2)) / det
+	y := (a00*(b1*a22-b2*a12) - b0*(a01*a22-a12*a02) + a02*(a01*b2-b1*a02)) / det
+	z := (a00*(a11*b2-a12*b1) - a01*(a01*b2-b1*a02) + b0*(a01*a12-a11*a02)) / det
+	return Vector3dType{float32(x), float32(y), float32(z)}, true
+}
+
+// simplifyVertex is a vertex of the indexed mesh used by simplification
+type simplifyVertex struct {
+	Vertex    clipVertex // position, color, set and UV
+	Quadric   quadric
+	Locked    bool  // vertices on boundaries and seams are never moved
+	Alive     bool  // false after the vertex has been collapsed
+	Stamp     int   // incremented on each change of the vertex (to invalidate heap entries)
+	Triangles []int // incident triangles (some of them may be dead)
+}
+
+// simplifyMesh is an indexed mesh used by simplification
+type simplifyMesh struct {
+	Vertices      []simplifyVertex
+	Triangles     [][3]int
+	TriangleAlive []bool
+	AliveCount    int
+}
+
+// simplifyCollapse is a candidate edge collapse in the heap
+type simplifyCollapse struct {
+	Cost      float64
+	U, V      int // V is collapsed into U
+	StampU    int
+	StampV    int
+	NewVertex clipVertex
+}
+
+type simplifyHeap []*simplifyCollapse
+
+func (h simplifyHeap) Len() int            { return len(h) }
+func (h simplifyHeap) Less(i, j int) bool  { return h[i].Cost < h[j].Cost }
+func (h simplifyHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *simplifyHeap) Push(x interface{}) { *h = append(*h, x.(*simplifyCollapse)) }
+func (h *simplifyHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// makeSimplifyMesh welds equal vertices (with equal position, color, set and UV) of the triangles
+// and computes the quadrics and the locked vertices.
+func makeSimplifyMesh(triangles [][3]clipVertex) *simplifyMesh {
+	mesh := &simplifyMesh{}
+	index := make(map[clipVertex]int)
+	positionCount := make(map[Vector3dType]int)
+	for _, triangle := range triangles {
+		var t [3]int
+		for j, vertex := range triangle {
+			i, ok := index[vertex]
+			if !ok {
+				i = len(mesh.Vertices)
+				index[vertex] = i
+				mesh.Vertices = append(mesh.Vertices, simplifyVertex{Vertex: vertex, Alive: true})
+				positionCount[vertex.Endpoint.Position]++
+			}
+			t[j] = i
+		}
+		if t[0] == t[1] || t[1] == t[2] || t[2] == t[0] {
+			continue // skip degenerated triangles
+		}
+		ti := len(mesh.Triangles)
+		mesh.Triangles = append(mesh.Triangles, t)
+		mesh.TriangleAlive = append(mesh.TriangleAlive, true)
+		for _, i := range t {
+			mesh.Vertices[i].Triangles = append(mesh.Vertices[i].Triangles, ti)
+		}
+		// plane quadric weighted by the area of the triangle
+		plane := TriangleType{triangle[0].Endpoint, triangle[1].Endpoint, triangle[2].Endpoint}
+		normal, area := triangleNormalArea(&plane)
+		if area > 0 {
+			p := plane[0].Position
+			a, b, c := float64(normal[0]), float64(normal[1]), float64(normal[2])
+			d := -(a*float64(p[0]) + b*float64(p[1]) + c*float64(p[2]))
+			q := planeQuadric(a, b, c, d, area)
+			for _, i := range t {
+				mesh.Vertices[i].Quadric = mesh.Vertices[i].Quadric.add(q)
+			}
+		}
+	}
+	mesh.AliveCount = len(mesh.Triangles)
+
+	// lock the vertices of seams (coinciding positions with different attributes) and of boundary edges
+	edgeCount := make(map[[2]int]int)
+	for _, t := range mesh.Triangles {
+		for j := 0; j < 3; j++ {
+			edgeCount[edgeKey(t[j], t[(j+1)%3])]++
+		}
+	}
+	for edge, count := range edgeCount {
+		if count != 2 {
+			mesh.Vertices[edge[0]].Locked = true
+			mesh.Vertices[edge[1]].Locked = true
+		}
+	}
+	for i := range mesh.Vertices {
+		if positionCount[mesh.Vertices[i].Vertex.Endpoint.Position] > 1 {
+			mesh.Vertices[i].Locked = true
+		}
+	}
+	return mesh
+}
+
+// edgeKey returns the ordered pair of the edge ends
+func edgeKey(a, b int) [2]int {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]int{a, b}
+}
+
+// triangleNormalArea returns the unit normal and the area of the triangle
+func triangleNormalArea(triangle *TriangleType) (Vector3dType, float64) {
+	p := triangle
+	ux, uy, uz := float64(p[1].Position[0]-p[0].Position[0]), float64(p[1].Position[1]-p[0].Position[1]), float64(p[1].Position[2]-p[0].Position[2])
+	vx, vy, vz := float64(p[2].Position[0]-p[0].Position[0]), float64(p[2].Position[1]-p[0].Position[1]), float64(p[2].Position[2]-p[0].Position[2])
+	nx, ny, nz := uy*vz-uz*vy, uz*vx-ux*vz, ux*vy-uy*vx
+	length := math.Sqrt(nx*nx + ny*ny + nz*nz)
+	if length == 0 {
+		return Vector3dType{}, 0
+	}
+	return Vector3dType{float32(nx / length), float32(ny / length), float32(nz / length)}, length / 2
+}
+
+// neighbors returns the set of vertices adjacent to vertex i by alive triangles
+func (mesh *simplifyMesh) neighbors(i int) map[int]bool {
+	result := make(map[int]bool)
+	for _, ti := range mesh.Vertices[i].Triangles {
+		if !mesh.TriangleAlive[ti] {
+			continue
+		}
+		for _, j := range mesh.Triangles[ti] {
+			if j != i {
+				result[j] = true
+			}
+		}
+	}
+	return result
+}
+
+// makeCollapse returns the best collapse of the edge (u,v) or nil if the edge cannot be collapsed
+func (mesh *simplifyMesh) makeCollapse(u, v int) *simplifyCollapse {
+	vu, vv := &mesh.Vertices[u], &mesh.Vertices[v]
+	if vu.Locked && vv.Locked {
+		return nil
+	}
+	if vv.Locked { // always collapse the free vertex into the locked one
+		u, v = v, u
+		vu, vv = vv, vu
+	}
+	q := vu.Quadric.add(vv.Quadric)
+
+	var best clipVertex
+	bestCost := math.Inf(1)
+	consider := func(vertex clipVertex) {
+		if cost := q.error(vertex.Endpoint.Position); cost < bestCost {
+			best, bestCost = vertex, cost
+		}
+	}
+	consider(vu.Vertex)
+	if !vu.Locked {
+		consider(vv.Vertex)
+		consider(interpolateVertex(vu.Vertex, vv.Vertex, 0.5))
+		if p, ok := q.minimum(); ok {
+			// interpolate the attributes at the projection of p on the edge
+			a, b := vu.Vertex.Endpoint.Position, vv.Vertex.Endpoint.Position
+			var num, den float32
+			for k := 0; k < 3; k++ {
+				num += (p[k] - a[k]) * (b[k] - a[k])
+				den += (b[k] - a[k]) * (b[k] - a[k])
+			}
+			t := float32(0)
+			if den > 0 {
+				t = num / den
+			}
+			if t < 0 {
+				t = 0
+			} else if t > 1 {
+				t = 1
+			}
+			vertex := interpolateVertex(vu.Vertex, vv.Vertex, t)
+			vertex.Endpoint.Position = p
+			consider(vertex)
+		}
+	}
+	if bestCost < 0 {
+		bestCost = 0 // rounding errors
+	}
+	return &simplifyCollapse{Cost: bestCost, U: u, V: v, StampU: vu.Stamp, StampV: vv.Stamp, NewVertex: best}
+}
+
+// canCollapse checks the link condition and that no triangle is flipped by the collapse c
+func (mesh *simplifyMesh) canCollapse(c *simplifyCollapse) bool {
+	// link condition: common neighbors of U and V are exactly the opposite vertices of the triangles on the edge
+	nu := mesh.neighbors(c.U)
+	nv := mesh.neighbors(c.V)
+	common := 0
+	for w := range nv {
+		if nu[w] {
+			common++
+		}
+	}
+	shared := 0
+	for _, ti := range mesh.Vertices[c.V].Triangles {
+		if !mesh.TriangleAlive[ti] {
+			continue
+		}
+		t := mesh.Triangles[ti]
+		if t[0] == c.U || t[1] == c.U || t[2] == c.U {
+			shared++
+		}
+	}
+	if common != shared {
+		return false
+	}
+
+	// no flipped or degenerated triangles
+	for _, i := range []int{c.U, c.V} {
+		for _, ti := range mesh.Vertices[i].Triangles {
+			if !mesh.TriangleAlive[ti] {
+				continue
+			}
+			t := mesh.Triangles[ti]
+			hasU := t[0] == c.U || t[1] == c.U || t[2] == c.U
+			hasV := t[0] == c.V || t[1] == c.V || t[2] == c.V
+			if hasU && hasV {
+				continue // removed by the collapse
+			}
+			var before, after TriangleType
+			for j := 0; j < 3; j++ {
+				before[j] = mesh.Vertices[t[j]].Vertex.Endpoint
+				after[j] = before[j]
+				if t[j] == c.U || t[j] == c.V {
+					after[j].Position = c.NewVertex.Endpoint.Position
+				}
+			}
+			n0, _ := triangleNormalArea(&before)
+			n1, area := triangleNormalArea(&after)
+			if area == 0 || n0[0]*n1[0]+n0[1]*n1[1]+n0[2]*n1[2] < 0.2 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// collapse applies the collapse c to the mesh
+func (mesh *simplifyMesh) collapse(c *simplifyCollapse) {
+	vu, vv := &mesh.Vertices[c.U], &mesh.Vertices[c.V]
+	for _, ti := range vv.Triangles {
+		if !mesh.TriangleAlive[ti] {
+			continue
+		}
+		t := &mesh.Triangles[ti]
+		if t[0] == c.U || t[1] == c.U || t[2] == c.U {
+			mesh.TriangleAlive[ti] = false
+			mesh.AliveCount--
+			continue
+		}
+		for j := 0; j < 3; j++ {
+			if t[j] == c.V {
+				t[j] = c.U
+			}
+		}
+		vu.Triangles = append(vu.Triangles, ti)
+	}
+	vu.Vertex = c.NewVertex
+	vu.Quadric = vu.Quadric.add(vv.Quadric)
+	vu.Stamp++
+	vv.Alive = false
+	vv.Triangles = nil
+}
+
+// simplifyTriangles simplifies the triangles with their vertex attributes
+func simplifyTriangles(triangles [][3]clipVertex, options SimplifyOptions) [][3]clipVertex {
+	mesh := makeSimplifyMesh(triangles)
+	target := options.target(len(triangles))
+
+	h := &simplifyHeap{}
+	pushed := make(map[[2]int]bool)
+	for _, t := range mesh.Triangles {
+		for j := 0; j < 3; j++ {
+			key := edgeKey(t[j], t[(j+1)%3])
+			if pushed[key] {
+				continue
+			}
+			pushed[key] = true
+			if c := mesh.makeCollapse(key[0], key[1]); c != nil {
+				heap.Push(h, c)
+			}
+		}
+	}
+
+	for mesh.AliveCount > target && h.Len() > 0 {
+		c := heap.Pop(h).(*simplifyCollapse)
+		vu, vv := &mesh.Vertices[c.U], &mesh.Vertices[c.V]
+		if !vu.Alive || !vv.Alive || vu.Stamp != c.StampU || vv.Stamp != c.StampV {
+			continue // outdated
+		}
+		if options.MaxError > 0 && c.Cost > float64(options.MaxError) {
+			break
+		}
+		if !mesh.canCollapse(c) {
+			continue
+		}
+		mesh.collapse(c)
+		for w := range mesh.neighbors(c.U) {
+			if nc := mesh.makeCollapse(c.U, w); nc != nil {
+				heap.Push(h, nc)
+			}
+		}
+	}
+
+	result := make([][3]clipVertex, 0, mesh.AliveCount)
+	for ti, t := range mesh.Triangles {
+		if mesh.TriangleAlive[ti] {
+			result = append(result, [3]clipVertex{
+				mesh.Vertices[t[0]].Vertex,
+				mesh.Vertices[t[1]].Vertex,
+				mesh.Vertices[t[2]].Vertex,
+			})
+		}
+	}
+	return result
+}
+
+// Simplify returns the simplified triangles obtained by quadric-error-metric edge collapses.
+// The boundaries of colors and sets (and open boundaries of the surface) are not changed.
+func (triangles TrianglesType) Simplify(options SimplifyOptions) TrianglesType {
+	input := make([][3]clipVertex, 0, len(triangles))
+	for _, triangle := range triangles {
+		input = append(input, [3]clipVertex{{Endpoint: triangle[0]}, {Endpoint: triangle[1]}, {Endpoint: triangle[2]}})
+	}
+	output := simplifyTriangles(input, options)
+	result := make([]TriangleType, 0, len(output))
+	for _, t := range output {
+		result = append(result, TriangleType{t[0].Endpoint, t[1].Endpoint, t[2].Endpoint})
+	}
+	return TrianglesType(result)
+}
+
+// Simplify returns the simplified textured triangles obtained by quadric-error-metric edge collapses.
+// The UV seams, the boundaries of colors and sets (and open boundaries of the surface) are not changed.
+func (texTriangles TexturedTrianglesType) Simplify(options SimplifyOptions) TexturedTrianglesType {
+	input := make([][3]clipVertex, 0, len(texTriangles))
+	for _, texTriangle := range texTriangles {
+		var t [3]clipVertex
+		for j := 0; j < 3; j++ {
+			t[j] = clipVertex{Endpoint: texTriangle.Triangle[j], UV: texTriangle.TriangleUV[j]}
+		}
+		input = append(input, t)
+	}
+	output := simplifyTriangles(input, options)
+	result := make([]TexturedTriangleType, 0, len(output))
+	for _, t := range output {
+		result = append(result, TexturedTriangleType{
+			Triangle:   TriangleType{t[0].Endpoint, t[1].Endpoint, t[2].Endpoint},
+			TriangleUV: TriangleUVType{t[0].UV, t[1].UV, t[2].UV},
+		})
+	}
+	return TexturedTrianglesType(result)
+}
+
+// Simplify returns a pointer to the copy of mki3dData with simplified triangles of the model
+// and of the texture elements (each sequence is simplified separately).
+// If options.TargetTriangles > 0, then it is converted to the ratio of the total number of triangles.
+// The segments are not changed.
+func (mki3dData *Mki3dType) Simplify(options SimplifyOptions) *Mki3dType {
+	if options.TargetTriangles > 0 {
+		total := len(mki3dData.Model.Triangles)
+		if mki3dData.Texture != nil {
+			for _, texEl := range mki3dData.Texture.Elements {
+				total += len(texEl.TexturedTriangles)
+			}
+		}
+		if total > 0 {
+			options.TargetRatio = float32(options.TargetTriangles) / float32(total)
+		}
+		options.TargetTriangles = 0
+	}
+
+	simplified := *mki3dData
+	simplified.Model = ModelType{
+		Segments:  mki3dData.Model.Segments,
+		Triangles: mki3dData.Model.Triangles.Simplify(options),
+	}
+	if mki3dData.Texture != nil {
+		elements := make([]TextureElementType, 0, len(mki3dData.Texture.Elements))
+		for _, texEl := range mki3dData.Texture.Elements {
+			elements = append(elements, TextureElementType{
+				Def:               texEl.Def,
+				TexturedTriangles: texEl.TexturedTriangles.Simplify(options),
+			})
+		}
+		simplified.Texture = &TextureType{Elements: TextureElementsType(elements), Index: mki3dData.Texture.Index}
+	}
+	return &simplified
+}
+
+// MakeLODChain returns the sequence of levels of detail of mki3dData.
+// The level 0 is mki3dData and each next level is simplified from the previous one
+// with the TargetRatio ratio (and with maxError limit, if maxError > 0).
+func (mki3dData *Mki3dType) MakeLODChain(levels int, ratio float32, maxError float32) []*Mki3dType {
+	chain := make([]*Mki3dType, 0, levels)
+	if levels < 1 {
+		return chain
+	}
+	chain = append(chain, mki3dData)
+	for i := 1; i < levels; i++ {
+		chain = append(chain, chain[i-1].Simplify(SimplifyOptions{TargetRatio: ratio, MaxError: maxError}))
+	}
+	return chain
+}
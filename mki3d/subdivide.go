@@ -0,0 +1,298 @@
+package mki3d
+
+/* subdivision of triangles */
+
+import (
+	"math"
+)
+
+// subdivisionTriangle is a triangle with UV coordinates of its endpoints and the index of its group
+// (the model triangles or a texture element) used by subdivision
+type subdivisionTriangle struct {
+	Vertices [3]clipVertex
+	Group    int
+}
+
+// midpointSubdivision splits each triangle into four triangles with the vertices in the midpoints of the edges.
+func midpointSubdivision(triangles []subdivisionTriangle) []subdivisionTriangle {
+	result := make([]subdivisionTriangle, 0, 4*len(triangles))
+	for _, t := range triangles {
+		var m [3]clipVertex // m[j] is the midpoint of the edge from j to j+1
+		for j := 0; j < 3; j++ {
+			m[j] = interpolateVertex(t.Vertices[j], t.Vertices[(j+1)%3], 0.5)
+		}
+		result = append(result, splitTriangle(t, t.Vertices, m)...)
+	}
+	return result
+}
+
+// splitTriangle returns four triangles of the group of t with corners c and edge midpoints m
+// (m[j] is on the edge from c[j] to c[j+1])
+func splitTriangle(t subdivisionTriangle, c [3]clipVertex, m [3]clipVertex) []subdivisionTriangle {
+	return []subdivisionTriangle{
+		{Vertices: [3]clipVertex{c[0], m[0], m[2]}, Group: t.Group},
+		{Vertices: [3]clipVertex{m[0], c[1], m[1]}, Group: t.Group},
+		{Vertices: [3]clipVertex{m[2], m[1], c[2]}, Group: t.Group},
+		{Vertices: [3]clipVertex{m[0], m[1], m[2]}, Group: t.Group},
+	}
+}
+
+// subdivisionEdge is an edge of the mesh welded by positions
+type subdivisionEdge struct {
+	Triangles []int        // triangles containing the edge
+	Locals    []int        // local index j of the edge (from j to j+1) in the triangles
+	Sharp     bool         // sharp edges are not smoothed
+	Point     Vector3dType // new position of the edge point
+}
+
+// loopSubdivision performs one step of Loop subdivision of the triangles.
+// The boundary edges are always sharp. If keepSharp is true, then the edges between triangles
+// with different colors, sets or groups are also sharp.
+func loopSubdivision(triangles []subdivisionTriangle, keepSharp bool) []subdivisionTriangle {
+	// weld the vertices by positions
+	positions := make([]Vector3dType, 0)
+	positionIndex := make(map[Vector3dType]int)
+	corners := make([][3]int, len(triangles))
+	for ti, t := range triangles {
+		for j := 0; j < 3; j++ {
+			p := t.Vertices[j].Endpoint.Position
+			i, ok := positionIndex[p]
+			if !ok {
+				i = len(positions)
+				positionIndex[p] = i
+				positions = append(positions, p)
+			}
+			corners[ti][j] = i
+		}
+	}
+
+	// collect edges
+	edges := make(map[[2]int]*subdivisionEdge)
+	for ti, c := range corners {
+		for j := 0; j < 3; j++ {
+			key := edgeKey(c[j], c[(j+1)%3])
+			e, ok := edges[key]
+			if !ok {
+				e = &subdivisionEdge{}
+				edges[key] = e
+			}
+			e.Triangles = append(e.Triangles, ti)
+			e.Locals = append(e.Locals, j)
+		}
+	}
+
+	// mark sharp edges and compute edge points
+	for key, e := range edges {
+		a, b := positions[key[0]], positions[key[1]]
+		e.Sharp = len(e.Triangles) != 2
+		if !e.Sharp && keepSharp {
+			t0, t1 := triangles[e.Triangles[0]], triangles[e.Triangles[1]]
+			e.Sharp = t0.Group != t1.Group ||
+				!sameEdgeAttributes(t0.Vertices[e.Locals[0]], t0.Vertices[(e.Locals[0]+1)%3], t1) ||
+				!sameEdgeAttributes(t0.Vertices[(e.Locals[0]+1)%3], t0.Vertices[e.Locals[0]], t1)
+		}
+		if e.Sharp {
+			e.Point = interpolate(a, b, 0.5)
+			continue
+		}
+		// opposite vertices of the two triangles
+		c := positions[corners[e.Triangles[0]][(e.Locals[0]+2)%3]]
+		d := positions[corners[e.Triangles[1]][(e.Locals[1]+2)%3]]
+		for k := 0; k < 3; k++ {
+			e.Point[k] = 3.0/8.0*(a[k]+b[k]) + 1.0/8.0*(c[k]+d[k])
+		}
+	}
+
+	// compute new positions of the original vertices
+	neighbors := make([][]int, len(positions))
+	sharpNeighbors := make([][]int, len(positions))
+	for key, e := range edges {
+		neighbors[key[0]] = append(neighbors[key[0]], key[1])
+		neighbors[key[1]] = append(neighbors[key[1]], key[0])
+		if e.Sharp {
+			sharpNeighbors[key[0]] = append(sharpNeighbors[key[0]], key[1])
+			sharpNeighbors[key[1]] = append(sharpNeighbors[key[1]], key[0])
+		}
+	}
+	newPositions := make([]Vector3dType, len(positions))
+	for i, p := range positions {
+		switch {
+		case len(sharpNeighbors[i]) > 2: // corner
+			newPositions[i] = p
+		case len(sharpNeighbors[i]) == 2: // crease
+			a, b := positions[sharpNeighbors[i][0]], positions[sharpNeighbors[i][1]]
+			for k := 0; k < 3; k++ {
+				newPositions[i][k] = 3.0/4.0*p[k] + 1.0/8.0*(a[k]+b[k])
+			}
+		default: // smooth
+			n := float64(len(neighbors[i]))
+			if n == 0 {
+				newPositions[i] = p
+				continue
+			}
+			c := 3.0/8.0 + 1.0/4.0*math.Cos(2*math.Pi/n)
+			beta := float32((5.0/8.0 - c*c) / n)
+			var sum Vector3dType
+			for _, j := range neighbors[i] {
+				for k := 0; k < 3; k++ {
+					sum[k] += positions[j][k]
+				}
+			}
+			for k := 0; k < 3; k++ {
+				newPositions[i][k] = (1-float32(n)*beta)*p[k] + beta*sum[k]
+			}
+		}
+	}
+
+	// make new triangles with interpolated colors and UVs
+	result := make([]subdivisionTriangle, 0, 4*len(triangles))
+	for ti, t := range triangles {
+		var c, m [3]clipVertex
+		for j := 0; j < 3; j++ {
+			c[j] = t.Vertices[j]
+			c[j].Endpoint.Position = newPositions[corners[ti][j]]
+			m[j] = interpolateVertex(t.Vertices[j], t.Vertices[(j+1)%3], 0.5)
+			m[j].Endpoint.Position = edges[edgeKey(corners[ti][j], corners[ti][(j+1)%3])].Point
+		}
+		result = append(result, splitTriangle(t, c, m)...)
+	}
+	return result
+}
+
+// sameEdgeAttributes returns true if the endpoints of triangle t at the positions of a and b
+// have the same colors and sets as a and b.
+func sameEdgeAttributes(a, b clipVertex, t subdivisionTriangle) bool {
+	for j := 0; j < 3; j++ {
+		v := t.Vertices[j].Endpoint
+		if v.Position == a.Endpoint.Position && (v.Color != a.Endpoint.Color || v.Set != a.Endpoint.Set) {
+			return false
+		}
+		if v.Position == b.Endpoint.Position && (v.Color != b.Endpoint.Color || v.Set != b.Endpoint.Set) {
+			return false
+		}
+	}
+	return true
+}
+
+// subdivisionInput converts triangles to the input of subdivision with the given group
+func (triangles TrianglesType) subdivisionInput(group int) []subdivisionTriangle {
+	input := make([]subdivisionTriangle, 0, len(triangles))
+	for _, triangle := range triangles {
+		input = append(input, subdivisionTriangle{
+			Vertices: [3]clipVertex{{Endpoint: triangle[0]}, {Endpoint: triangle[1]}, {Endpoint: triangle[2]}},
+			Group:    group,
+		})
+	}
+	return input
+}
+
+// subdivisionInput converts textured triangles to the input of subdivision with the given group
+func (texTriangles TexturedTrianglesType) subdivisionInput(group int) []subdivisionTriangle {
+	input := make([]subdivisionTriangle, 0, len(texTriangles))
+	for _, texTriangle := range texTriangles {
+		t := subdivisionTriangle{Group: group}
+		for j := 0; j < 3; j++ {
+			t.Vertices[j] = clipVertex{Endpoint: texTriangle.Triangle[j], UV: texTriangle.TriangleUV[j]}
+		}
+		input = append(input, t)
+	}
+	return input
+}
+
+// subdivisionOutputTriangles returns the triangles of the group from the output of subdivision
+func subdivisionOutputTriangles(output []subdivisionTriangle, group int) TrianglesType {
+	triangles := make([]TriangleType, 0, len(output))
+	for _, t := range output {
+		if t.Group == group {
+			triangles = append(triangles, TriangleType{t.Vertices[0].Endpoint, t.Vertices[1].Endpoint, t.Vertices[2].Endpoint})
+		}
+	}
+	return TrianglesType(triangles)
+}
+
+// subdivisionOutputTextured returns the textured triangles of the group from the output of subdivision
+func subdivisionOutputTextured(output []subdivisionTriangle, group int) TexturedTrianglesType {
+	texTriangles := make([]TexturedTriangleType, 0, len(output))
+	for _, t := range output {
+		if t.Group == group {
+			texTriangles = append(texTriangles, TexturedTriangleType{
+				Triangle:   TriangleType{t.Vertices[0].Endpoint, t.Vertices[1].Endpoint, t.Vertices[2].Endpoint},
+				TriangleUV: TriangleUVType{t.Vertices[0].UV, t.Vertices[1].UV, t.Vertices[2].UV},
+			})
+		}
+	}
+	return TexturedTrianglesType(texTriangles)
+}
+
+// SubdivideMidpoint returns the triangles obtained by splitting each triangle into four triangles
+// with the vertices in the midpoints of the edges. The colors are interpolated.
+func (triangles TrianglesType) SubdivideMidpoint() TrianglesType {
+	return subdivisionOutputTriangles(midpointSubdivision(triangles.subdivisionInput(0)), 0)
+}
+
+// SubdivideMidpoint returns the textured triangles obtained by splitting each triangle into four triangles
+// with the vertices in the midpoints of the edges. The colors and UV coordinates are interpolated.
+func (texTriangles TexturedTrianglesType) SubdivideMidpoint() TexturedTrianglesType {
+	return subdivisionOutputTextured(midpointSubdivision(texTriangles.subdivisionInput(0)), 0)
+}
+
+// SubdivideLoop returns the triangles obtained by one step of Loop subdivision.
+// The colors are interpolated linearly and the sets of the endpoints are preserved.
+// The boundary edges are always kept sharp. If keepSharp is true, then also the edges
+// between triangles with different colors or sets are kept sharp.
+func (triangles TrianglesType) SubdivideLoop(keepSharp bool) TrianglesType {
+	return subdivisionOutputTriangles(loopSubdivision(triangles.subdivisionInput(0), keepSharp), 0)
+}
+
+// SubdivideLoop returns the textured triangles obtained by one step of Loop subdivision.
+// The colors and UV coordinates are interpolated linearly and the sets of the endpoints are preserved.
+// The boundary edges are always kept sharp. If keepSharp is true, then also the edges
+// between triangles with different colors or sets are kept sharp.
+func (texTriangles TexturedTrianglesType) SubdivideLoop(keepSharp bool) TexturedTrianglesType {
+	return subdivisionOutputTextured(loopSubdivision(texTriangles.subdivisionInput(0), keepSharp), 0)
+}
+
+// subdivide applies subdivision to the triangles of the model and of all texture elements of mki3dData together
+// (so that there are no cracks between them) and returns a pointer to the subdivided copy of mki3dData.
+func (mki3dData *Mki3dType) subdivide(subdivision func([]subdivisionTriangle) []subdivisionTriangle) *Mki3dType {
+	input := mki3dData.Model.Triangles.subdivisionInput(0)
+	if mki3dData.Texture != nil {
+		for i, texEl := range mki3dData.Texture.Elements {
+			input = append(input, texEl.TexturedTriangles.subdivisionInput(i+1)...)
+		}
+	}
+	output := subdivision(input)
+
+	subdivided := *mki3dData
+	subdivided.Model = ModelType{
+		Segments:  mki3dData.Model.Segments,
+		Triangles: subdivisionOutputTriangles(output, 0),
+	}
+	if mki3dData.Texture != nil {
+		elements := make([]TextureElementType, 0, len(mki3dData.Texture.Elements))
+		for i, texEl := range mki3dData.Texture.Elements {
+			elements = append(elements, TextureElementType{
+				Def:               texEl.Def,
+				TexturedTriangles: subdivisionOutputTextured(output, i+1),
+			})
+		}
+		subdivided.Texture = &TextureType{Elements: TextureElementsType(elements), Index: mki3dData.Texture.Index}
+	}
+	return &subdivided
+}
+
+// SubdivideMidpoint returns a pointer to the copy of mki3dData with midpoint subdivision applied
+// to the triangles of the model and of the texture elements. The segments are not changed.
+func (mki3dData *Mki3dType) SubdivideMidpoint() *Mki3dType {
+	return mki3dData.subdivide(midpointSubdivision)
+}
+
+// SubdivideLoop returns a pointer to the copy of mki3dData with one step of Loop subdivision applied
+// to the triangles of the model and of the texture elements together. If keepSharp is true, then
+// the edges between triangles with different colors, sets or textures are kept sharp.
+// The segments are not changed.
+func (mki3dData *Mki3dType) SubdivideLoop(keepSharp bool) *Mki3dType {
+	return mki3dData.subdivide(func(triangles []subdivisionTriangle) []subdivisionTriangle {
+		return loopSubdivision(triangles, keepSharp)
+	})
+}
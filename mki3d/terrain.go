@@ -0,0 +1,175 @@
+package mki3d
+
+/* terrain models from heightmaps */
+
+import (
+	"image"
+	"image/color"
+)
+
+// GradientStopType is a color at a relative height (0 at the lowest point and 1 at the highest point of a terrain)
+type GradientStopType struct {
+	Height float32
+	Color  Vector3dType
+}
+
+// DefaultTerrainGradient is used by terrain generators if TerrainOptions.Gradient is empty
+var DefaultTerrainGradient = []GradientStopType{
+	{Height: 0, Color: Vector3dType{0.1, 0.2, 0.6}},    // water
+	{Height: 0.1, Color: Vector3dType{0.8, 0.75, 0.5}}, // sand
+	{Height: 0.3, Color: Vector3dType{0.2, 0.6, 0.2}},  // grass
+	{Height: 0.7, Color: Vector3dType{0.5, 0.45, 0.4}}, // rock
+	{Height: 1, Color: Vector3dType{1, 1, 1}},          // snow
+}
+
+// TerrainOptions are the parameters of terrain generators
+type TerrainOptions struct {
+	CellSize    float32            // distance between neighbouring grid points in X and Z directions (1 if not positive)
+	HeightScale float32            // the heights (Y coordinates) are the values of the heightmap multiplied by HeightScale
+	Gradient    []GradientStopType // colors sorted by relative heights (DefaultTerrainGradient if empty)
+	Skirt       float32            // if positive, vertical walls are added on the border down to Skirt below the lowest point
+	Set         int                // set index of the endpoints
+}
+
+// gradientColor returns the color of the gradient at the relative height h
+func gradientColor(gradient []GradientStopType, h float32) Vector3dType {
+	if len(gradient) == 0 {
+		gradient = DefaultTerrainGradient
+	}
+	if h <= gradient[0].Height {
+		return gradient[0].Color
+	}
+	for i := 1; i < len(gradient); i++ {
+		if h <= gradient[i].Height {
+			a, b := gradient[i-1], gradient[i]
+			return interpolate(a.Color, b.Color, (h-a.Height)/(b.Height-a.Height))
+		}
+	}
+	return gradient[len(gradient)-1].Color
+}
+
+// HeightsFromImage returns the heightmap with the gray levels (in the range [0,1]) of the pixels of img.
+// The rows of the heightmap are the rows of the image from the top.
+func HeightsFromImage(img image.Image) [][]float32 {
+	bounds := img.Bounds()
+	heights := make([][]float32, 0, bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row := make([]float32, 0, bounds.Dx())
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			gray := color.Gray16Model.Convert(img.At(x, y)).(color.Gray16)
+			row = append(row, float32(gray.Y)/0xffff)
+		}
+		heights = append(heights, row)
+	}
+	return heights
+}
+
+// MakeTerrainTextured returns the textured triangles of the terrain with the heights from the heightmap.
+// The grid is centered at the origin in XZ plane with the rows of heights along the Z axis and the columns along
+// the X axis. The UV coordinates span the whole texture over the terrain with the first row at V = 1.
+// It returns empty TexturedTrianglesType if heights has less than two rows or columns.
+func MakeTerrainTextured(heights [][]float32, options TerrainOptions) TexturedTrianglesType {
+	rows := len(heights)
+	columns := 0
+	for i, row := range heights { // use the shortest row
+		if i == 0 || len(row) < columns {
+			columns = len(row)
+		}
+	}
+	if rows < 2 || columns < 2 {
+		return TexturedTrianglesType{}
+	}
+
+	minHeight, maxHeight := heights[0][0], heights[0][0]
+	for r := 0; r < rows; r++ {
+		for c := 0; c < columns; c++ {
+			if heights[r][c] < minHeight {
+				minHeight = heights[r][c]
+			}
+			if heights[r][c] > maxHeight {
+				maxHeight = heights[r][c]
+			}
+		}
+	}
+	cellSize := options.CellSize
+	if cellSize <= 0 {
+		cellSize = 1
+	}
+	relative := func(h float32) float32 {
+		if maxHeight == minHeight {
+			return 0
+		}
+		return (h - minHeight) / (maxHeight - minHeight)
+	}
+
+	vertex := func(r, c int, h float32) clipVertex {
+		position := Vector3dType{
+			cellSize * (float32(c) - float32(columns-1)/2),
+			options.HeightScale * h,
+			cellSize * (float32(r) - float32(rows-1)/2),
+		}
+		return clipVertex{
+			Endpoint: EndpointType{Position: position, Color: gradientColor(options.Gradient, relative(h)), Set: options.Set},
+			UV:       Vector2dType{float32(c) / float32(columns-1), 1 - float32(r)/float32(rows-1)},
+		}
+	}
+	texTriangle := func(a, b, c clipVertex) TexturedTriangleType {
+		return TexturedTriangleType{
+			Triangle:   TriangleType{a.Endpoint, b.Endpoint, c.Endpoint},
+			TriangleUV: TriangleUVType{a.UV, b.UV, c.UV},
+		}
+	}
+
+	texTriangles := make(TexturedTrianglesType, 0, 2*(rows-1)*(columns-1))
+	for r := 0; r+1 < rows; r++ {
+		for c := 0; c+1 < columns; c++ {
+			p00 := vertex(r, c, heights[r][c])
+			p01 := vertex(r, c+1, heights[r][c+1])
+			p10 := vertex(r+1, c, heights[r+1][c])
+			p11 := vertex(r+1, c+1, heights[r+1][c+1])
+			// facing up
+			texTriangles = append(texTriangles, texTriangle(p00, p10, p11), texTriangle(p00, p11, p01))
+		}
+	}
+
+	if options.Skirt > 0 {
+		// in world units, since a negative HeightScale turns the lowest point of the heightmap into the highest one
+		bottom := options.HeightScale*minHeight - options.Skirt
+		if y := options.HeightScale*maxHeight - options.Skirt; y < bottom {
+			bottom = y
+		}
+		// the border as a closed loop of grid points
+		border := make([][2]int, 0, 2*(rows+columns))
+		for c := 0; c < columns-1; c++ {
+			border = append(border, [2]int{0, c})
+		}
+		for r := 0; r < rows-1; r++ {
+			border = append(border, [2]int{r, columns - 1})
+		}
+		for c := columns - 1; c > 0; c-- {
+			border = append(border, [2]int{rows - 1, c})
+		}
+		for r := rows - 1; r > 0; r-- {
+			border = append(border, [2]int{r, 0})
+		}
+		for i, a := range border {
+			b := border[(i+1)%len(border)]
+			pa, pb := vertex(a[0], a[1], heights[a[0]][a[1]]), vertex(b[0], b[1], heights[b[0]][b[1]])
+			qa, qb := pa, pb // with the colors of the border
+			qa.Endpoint.Position[1], qb.Endpoint.Position[1] = bottom, bottom
+			// the loop goes clockwise when seen from above, so these triangles face outwards
+			texTriangles = append(texTriangles, texTriangle(pa, pb, qb), texTriangle(pa, qb, qa))
+		}
+	}
+	return texTriangles
+}
+
+// MakeTerrain returns the model of the terrain with the heights from the heightmap (see MakeTerrainTextured).
+func MakeTerrain(heights [][]float32, options TerrainOptions) ModelType {
+	return makeTrianglesModel(MakeTerrainTextured(heights, options))
+}
+
+// MakeTerrainFromImage returns the model of the terrain with the heights from the gray levels of img.
+func MakeTerrainFromImage(img image.Image, options TerrainOptions) ModelType {
+	return MakeTerrain(HeightsFromImage(img), options)
+}
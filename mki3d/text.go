@@ -0,0 +1,103 @@
+package mki3d
+
+/* text as MKI3D geometry */
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"strings"
+)
+
+// TextOptions are the parameters of the layout of text
+type TextOptions struct {
+	Origin      Vector3dType // the point on the baseline at the beginning of the first line
+	Right       Vector3dType // direction of the lines of text
+	Up          Vector3dType // direction from the baseline to the top of the letters
+	Size        float32      // height of capital letters
+	Color       Vector3dType // color of the endpoints
+	Set         int          // set index of the endpoints
+	StrokeWidth float32      // width of the strokes of TextTriangles (0 means Size/8)
+}
+
+// TextWidth returns the width of the longest line of text drawn with the capital letters of the given size.
+func TextWidth(text string, size float32) float32 {
+	longest := 0
+	for _, line := range strings.Split(text, "\n") {
+		if n := len([]rune(line)); n > longest {
+			longest = n
+		}
+	}
+	if longest == 0 {
+		return 0
+	}
+	// the last character has no spacing after it
+	return size / strokeFontCap * float32(longest*strokeFontAdvance-2)
+}
+
+// textStrokes returns the strokes of text in the plane of options as pairs of endpoints
+func textStrokes(text string, options TextOptions) SegmentsType {
+	right := mgl32.Vec3(options.Right).Normalize()
+	up := mgl32.Vec3(options.Up).Normalize()
+	scale := options.Size / strokeFontCap
+	origin := mgl32.Vec3(options.Origin)
+	point := func(line, column int, p Vector2dType) EndpointType {
+		x := scale * (float32(column*strokeFontAdvance) + p[0])
+		y := scale * (p[1] - float32(line*strokeFontLine))
+		position := origin.Add(right.Mul(x)).Add(up.Mul(y))
+		return EndpointType{Position: Vector3dType(position), Color: options.Color, Set: options.Set}
+	}
+
+	segments := make(SegmentsType, 0)
+	for line, lineText := range strings.Split(text, "\n") {
+		for column, r := range []rune(lineText) {
+			for _, polyline := range strokeGlyph(r) {
+				for i := 0; i+1 < len(polyline); i++ {
+					segments = append(segments, SegmentType{point(line, column, polyline[i]), point(line, column, polyline[i+1])})
+				}
+			}
+		}
+	}
+	return segments
+}
+
+// TextSegments returns the segments of text drawn with the built-in stroke font in the plane
+// spanned by options.Right and options.Up. The lines of text are separated by '\n'.
+// The characters missing in the font are drawn as boxes.
+func TextSegments(text string, options TextOptions) SegmentsType {
+	return textStrokes(text, options)
+}
+
+// TextTriangles returns the triangles of text drawn with the built-in stroke font as in TextSegments,
+// with each stroke replaced by a bar of the width options.StrokeWidth extruded by depth
+// behind the plane of the text (in the direction opposite to Right x Up).
+func TextTriangles(text string, depth float32, options TextOptions) TrianglesType {
+	width := options.StrokeWidth
+	if width == 0 {
+		width = options.Size / 8
+	}
+	normal := mgl32.Vec3(options.Right).Cross(mgl32.Vec3(options.Up)).Normalize()
+	primitiveOptions := PrimitiveOptions{Color: options.Color, Set: options.Set}
+
+	triangles := make(TrianglesType, 0)
+	for _, segment := range textStrokes(text, options) {
+		a, b := mgl32.Vec3(segment[0].Position), mgl32.Vec3(segment[1].Position)
+		length := b.Sub(a).Len()
+		if length == 0 {
+			continue
+		}
+		// the frame of the bar: ex along the stroke, ey across the stroke in the plane, ez = normal
+		ex := b.Sub(a).Mul(1 / length)
+		ey := normal.Cross(ex)
+		center := a.Add(b).Mul(0.5).Sub(normal.Mul(depth / 2))
+		// square ends of the width of the stroke cover the joints of polylines
+		bar := MakeBox(Vector3dType{length + width, width, depth}, primitiveOptions)
+		for _, triangle := range bar.Triangles {
+			for j := 0; j < 3; j++ {
+				p := triangle[j].Position
+				q := center.Add(ex.Mul(p[0])).Add(ey.Mul(p[1])).Add(normal.Mul(p[2]))
+				triangle[j].Position = Vector3dType(q)
+			}
+			triangles = append(triangles, triangle)
+		}
+	}
+	return triangles
+}
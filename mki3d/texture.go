@@ -47,6 +47,21 @@ func (texTriangles TexturedTrianglesType) GetUVArrays() []float32 {
 	return data
 }
 
+// Gets interleaved array which is a sequence of endpoints' positions, normals and UV coordinates
+// of textured triangles (TexturedInterleavedSize float32 values per endpoint).
+func (texTriangles TexturedTrianglesType) GetInterleavedArray() []float32 {
+	data := make([]float32, 0, 3*TexturedInterleavedSize*len(texTriangles))
+	for _, texTriangle := range texTriangles {
+		normal := texTriangle.Triangle.Normal()
+		for j := 0; j < 3; j++ {
+			data = append(data, texTriangle.Triangle[j].Position[0:3]...)
+			data = append(data, normal[0:3]...)
+			data = append(data, texTriangle.TriangleUV[j][0:2]...)
+		}
+	}
+	return data
+}
+
 // TextureElementType is a texture definition with the sequence of triangles textured with this texture.
 type TextureElementType struct {
 	Def               TexturionDefType      `json:"def"`
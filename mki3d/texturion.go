@@ -0,0 +1,101 @@
+package mki3d
+
+/* evaluation of Texturion textures on CPU */
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// maxTexturionDepth limits the nesting of calls of R, G, B, A in Texturion expressions
+const maxTexturionDepth = 16
+
+// TexturionType is a Texturion definition parsed for evaluation on CPU.
+// See: https://mki1967.github.io/texturion/
+type TexturionType struct {
+	R, G, B, A *ExpressionType
+	depth      int // current nesting of the calls of R, G, B, A
+}
+
+// normalizeSpace returns s trimmed, with each run of white space replaced with a single space
+// or a single new line (if the run contains a new line, which ends a // comment in GLSL)
+func normalizeSpace(s string) string {
+	var b strings.Builder
+	space, newLine := false, false
+	for _, r := range strings.TrimSpace(s) {
+		if unicode.IsSpace(r) {
+			space = true
+			newLine = newLine || r == '\n'
+			continue
+		}
+		if newLine {
+			b.WriteRune('\n')
+		} else if space {
+			b.WriteRune(' ')
+		}
+		space, newLine = false, false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Normalized returns def without the label and with the white space in the expressions normalized,
+// so that the definitions of the same texture are equal. As in the texture generator,
+// definitions containing ';' or '}' are replaced with black.
+func (def TexturionDefType) Normalized() TexturionDefType {
+	if strings.ContainsAny(def.R+def.G+def.B+def.A, ";}") {
+		return TexturionDefType{R: "0.0", G: "0.0", B: "0.0", A: "1.0"}
+	}
+	return TexturionDefType{R: normalizeSpace(def.R), G: normalizeSpace(def.G), B: normalizeSpace(def.B), A: normalizeSpace(def.A)}
+}
+
+// MakeTexturion either returns a pointer to the parsed definition def or an error.
+// As in the texture generator, definitions containing ';' or '}' are replaced with black.
+func MakeTexturion(def TexturionDefType) (*TexturionType, error) {
+	def = def.Normalized()
+	var t TexturionType
+	call := func(e **ExpressionType) func(args ...float64) float64 {
+		return func(args ...float64) float64 {
+			if t.depth >= maxTexturionDepth || len(args) != 2 {
+				return 0
+			}
+			t.depth++
+			defer func() { t.depth-- }()
+			return (*e).Eval(args...)
+		}
+	}
+	functions := ExpressionFunctions{"R": call(&t.R), "G": call(&t.G), "B": call(&t.B), "A": call(&t.A)}
+	variables := []string{"x", "y"}
+	var err error
+	for _, p := range []struct {
+		e      **ExpressionType
+		source string
+	}{{&t.R, def.R}, {&t.G, def.G}, {&t.B, def.B}, {&t.A, def.A}} {
+		*p.e, err = ParseExpression(p.source, variables, functions)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &t, nil
+}
+
+// Eval returns the values of R, G, B, A of the texture at the point (x, y) in [-1,1]x[-1,1].
+func (t *TexturionType) Eval(x, y float64) (r, g, b, a float64) {
+	return t.R.Eval(x, y), t.G.Eval(x, y), t.B.Eval(x, y), t.A.Eval(x, y)
+}
+
+// Sample returns the RGB color (clamped to [0,1]) of the texture at the UV coordinates uv (repeated outside of [0,1]).
+func (t *TexturionType) Sample(uv Vector2dType) Vector3dType {
+	x := 2*(float64(uv[0])-math.Floor(float64(uv[0]))) - 1
+	y := 2*(float64(uv[1])-math.Floor(float64(uv[1]))) - 1
+	r, g, b, _ := t.Eval(x, y)
+	var color Vector3dType
+	for k, c := range []float64{r, g, b} {
+		if math.IsNaN(c) {
+			c = 0
+		}
+		color[k] = float32(math.Min(math.Max(c, 0), 1))
+	}
+	return color
+}
@@ -0,0 +1,194 @@
+package mki3d
+
+/* conversion of segments to solid tubes */
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"math"
+)
+
+// TubeOptions are the parameters of conversion of segments to tubes
+type TubeOptions struct {
+	Radius float32 // radius of the tubes
+	Sides  int     // number of sides of the tubes (at least 3)
+	Caps   bool    // close the ends of the tubes that have no joint spheres with flat caps
+	Joints bool    // put spheres at the endpoints shared by two or more segments
+}
+
+// tubeFrame returns two unit vectors u, v such that (u, v, d) is an orthonormal right-handed frame
+// (d should be a unit vector)
+func tubeFrame(d mgl32.Vec3) (u, v mgl32.Vec3) {
+	// cross d with the axis least aligned with it
+	k := 0
+	for i := 1; i < 3; i++ {
+		if math.Abs(float64(d[i])) < math.Abs(float64(d[k])) {
+			k = i
+		}
+	}
+	var axis mgl32.Vec3
+	axis[k] = 1
+	u = d.Cross(axis).Normalize()
+	v = d.Cross(u)
+	return u, v
+}
+
+// tubeRing returns the endpoints on the circle of the given radius around center in the plane spanned by u and v.
+// The endpoints have the color and set of endpoint.
+func tubeRing(endpoint EndpointType, center, u, v mgl32.Vec3, radius float32, sides int) []EndpointType {
+	ring := make([]EndpointType, sides)
+	for i := 0; i < sides; i++ {
+		angle := 2 * math.Pi * float64(i) / float64(sides)
+		p := center.Add(u.Mul(radius * float32(math.Cos(angle)))).Add(v.Mul(radius * float32(math.Sin(angle))))
+		ring[i] = endpoint
+		ring[i].Position = Vector3dType(p)
+	}
+	return ring
+}
+
+// tubeCap returns the triangles closing the ring with the center endpoint.
+// If reverse is true, then the triangles face the other way.
+func tubeCap(center EndpointType, ring []EndpointType, reverse bool) TrianglesType {
+	triangles := make(TrianglesType, 0, len(ring))
+	for i := range ring {
+		next := ring[(i+1)%len(ring)]
+		if reverse {
+			triangles = append(triangles, TriangleType{center, next, ring[i]})
+		} else {
+			triangles = append(triangles, TriangleType{center, ring[i], next})
+		}
+	}
+	return triangles
+}
+
+// Tube returns the triangles of the tube with the given radius and number of sides around segment.
+// The ends of the tube are closed with flat caps if caps is true.
+// The endpoints of the triangles have the colors and sets of the corresponding endpoints of segment.
+// It returns nil for segments of zero length.
+func (segment *SegmentType) Tube(radius float32, sides int, caps bool) TrianglesType {
+	return segment.tube(radius, sides, caps, caps)
+}
+
+// tube returns the triangles of the tube around segment with optional caps at both ends
+func (segment *SegmentType) tube(radius float32, sides int, cap0, cap1 bool) TrianglesType {
+	if sides < 3 {
+		sides = 3
+	}
+	a := mgl32.Vec3(segment[0].Position)
+	b := mgl32.Vec3(segment[1].Position)
+	d := b.Sub(a)
+	if d.Dot(d) == 0 {
+		return nil
+	}
+	u, v := tubeFrame(d.Normalize())
+	ringA := tubeRing(segment[0], a, u, v, radius, sides)
+	ringB := tubeRing(segment[1], b, u, v, radius, sides)
+
+	triangles := make(TrianglesType, 0, 4*sides)
+	for i := 0; i < sides; i++ {
+		j := (i + 1) % sides
+		// counterclockwise when seen from outside
+		triangles = append(triangles,
+			TriangleType{ringA[i], ringB[j], ringB[i]},
+			TriangleType{ringA[i], ringA[j], ringB[j]},
+		)
+	}
+	if cap0 {
+		triangles = append(triangles, tubeCap(segment[0], ringA, true)...)
+	}
+	if cap1 {
+		triangles = append(triangles, tubeCap(segment[1], ringB, false)...)
+	}
+	return triangles
+}
+
+// Sphere returns the triangles of the sphere with the given radius and the center at the position of endpoint.
+// The sphere has sides meridians and sides/2 (at least 2) parallel bands.
+// The endpoints of the triangles have the color and set of endpoint.
+func (endpoint *EndpointType) Sphere(radius float32, sides int) TrianglesType {
+	if sides < 3 {
+		sides = 3
+	}
+	bands := sides / 2
+	if bands < 2 {
+		bands = 2
+	}
+	center := mgl32.Vec3(endpoint.Position)
+	point := func(band, i int) EndpointType {
+		theta := math.Pi * float64(band) / float64(bands) // from the north pole
+		phi := 2 * math.Pi * float64(i) / float64(sides)
+		p := mgl32.Vec3{
+			float32(math.Sin(theta) * math.Cos(phi)),
+			float32(math.Cos(theta)),
+			float32(-math.Sin(theta) * math.Sin(phi)),
+		}
+		e := *endpoint
+		e.Position = Vector3dType(center.Add(p.Mul(radius)))
+		return e
+	}
+
+	triangles := make(TrianglesType, 0, 2*sides*bands)
+	for band := 0; band < bands; band++ {
+		for i := 0; i < sides; i++ {
+			p00, p01 := point(band, i), point(band, i+1)
+			p10, p11 := point(band+1, i), point(band+1, i+1)
+			if band > 0 {
+				triangles = append(triangles, TriangleType{p00, p10, p01})
+			}
+			if band < bands-1 {
+				triangles = append(triangles, TriangleType{p01, p10, p11})
+			}
+		}
+	}
+	return triangles
+}
+
+// Tubes returns the triangles of the tubes around the segments.
+// If options.Joints is true, then the spheres of the tube radius are put at the positions
+// shared by two or more segments (with the color and set of the first endpoint at the position).
+// If options.Caps is true, then the remaining ends of the tubes are closed with flat caps.
+func (segments SegmentsType) Tubes(options TubeOptions) TrianglesType {
+	// count segments meeting at each position
+	count := make(map[Vector3dType]int)
+	first := make(map[Vector3dType]EndpointType)
+	order := make([]Vector3dType, 0) // positions in the order of appearance
+	for _, segment := range segments {
+		if segment[0].Position == segment[1].Position {
+			continue
+		}
+		for j := 0; j < 2; j++ {
+			p := segment[j].Position
+			if _, ok := first[p]; !ok {
+				first[p] = segment[j]
+				order = append(order, p)
+			}
+			count[p]++
+		}
+	}
+	joint := func(p Vector3dType) bool {
+		return options.Joints && count[p] > 1
+	}
+
+	triangles := make(TrianglesType, 0)
+	for i := range segments {
+		segment := &segments[i]
+		cap0 := options.Caps && !joint(segment[0].Position)
+		cap1 := options.Caps && !joint(segment[1].Position)
+		triangles = append(triangles, segment.tube(options.Radius, options.Sides, cap0, cap1)...)
+	}
+	for _, p := range order {
+		if joint(p) {
+			endpoint := first[p]
+			triangles = append(triangles, endpoint.Sphere(options.Radius, options.Sides)...)
+		}
+	}
+	return triangles
+}
+
+// TubesModel returns the copy of model with the segments replaced by the triangles of tubes
+// appended to the triangles of the model.
+func (model *ModelType) TubesModel(options TubeOptions) ModelType {
+	triangles := make(TrianglesType, 0, len(model.Triangles))
+	triangles = append(triangles, model.Triangles...)
+	triangles = append(triangles, model.Segments.Tubes(options)...)
+	return ModelType{Segments: SegmentsType{}, Triangles: triangles}
+}
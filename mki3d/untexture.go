@@ -0,0 +1,90 @@
+package mki3d
+
+/* conversion of textured triangles to colored triangles */
+
+// UntextureOptions are the parameters of RemoveTextures
+type UntextureOptions struct {
+	Tolerance float32 // if positive, triangles are subdivided until the colors interpolated at the midpoints differ from the texture by at most Tolerance
+	MaxLevels int     // maximal number of subdivisions of each triangle (default 4)
+}
+
+// colorDifference returns the maximal difference of the components of colors a and b
+func colorDifference(a, b Vector3dType) float32 {
+	d := float32(0)
+	for k := 0; k < 3; k++ {
+		x := a[k] - b[k]
+		if x < 0 {
+			x = -x
+		}
+		if x > d {
+			d = x
+		}
+	}
+	return d
+}
+
+// sampledTriangles returns the triangles of the textured triangle with the endpoint colors sampled from texturion,
+// subdivided up to levels times if the texture is not approximated within tolerance
+func sampledTriangles(t subdivisionTriangle, texturion *TexturionType, tolerance float32, levels int) TrianglesType {
+	var triangle TriangleType
+	for j := 0; j < 3; j++ {
+		triangle[j] = t.Vertices[j].Endpoint
+		triangle[j].Color = texturion.Sample(t.Vertices[j].UV)
+	}
+	if tolerance > 0 && levels > 0 {
+		// compare the texture with the interpolated colors at the midpoints of the edges and at the centroid
+		var centroidUV Vector2dType
+		var centroidColor Vector3dType
+		split := false
+		for j := 0; j < 3 && !split; j++ {
+			m := interpolateVertex(t.Vertices[j], t.Vertices[(j+1)%3], 0.5)
+			interpolated := interpolate(triangle[j].Color, triangle[(j+1)%3].Color, 0.5)
+			split = colorDifference(texturion.Sample(m.UV), interpolated) > tolerance
+			for k := 0; k < 3; k++ {
+				centroidColor[k] += triangle[j].Color[k] / 3
+			}
+			for k := 0; k < 2; k++ {
+				centroidUV[k] += t.Vertices[j].UV[k] / 3
+			}
+		}
+		split = split || colorDifference(texturion.Sample(centroidUV), centroidColor) > tolerance
+		if split {
+			triangles := make(TrianglesType, 0, 4)
+			for _, part := range midpointSubdivision([]subdivisionTriangle{t}) {
+				triangles = append(triangles, sampledTriangles(part, texturion, tolerance, levels-1)...)
+			}
+			return triangles
+		}
+	}
+	return TrianglesType{triangle}
+}
+
+// RemoveTextures either returns a pointer to the copy of mki3dData without textures, with the textured triangles
+// moved to the triangles of the model with endpoint colors sampled from the Texturion textures, or an error
+// if a texture definition can not be parsed. If options.Tolerance is positive, then the triangles are subdivided
+// to capture the details of the textures (this may create T-junctions between triangles subdivided to different levels).
+func (mki3dData *Mki3dType) RemoveTextures(options UntextureOptions) (*Mki3dType, error) {
+	triangles := make(TrianglesType, 0, len(mki3dData.Model.Triangles))
+	triangles = append(triangles, mki3dData.Model.Triangles...)
+
+	levels := options.MaxLevels
+	if levels <= 0 {
+		levels = 4
+	}
+	if mki3dData.Texture != nil {
+		for _, texEl := range mki3dData.Texture.Elements {
+			texturion, err := MakeTexturion(texEl.Def)
+			if err != nil {
+				return nil, err
+			}
+			for _, t := range texEl.TexturedTriangles.subdivisionInput(0) {
+				triangles = append(triangles, sampledTriangles(t, texturion, options.Tolerance, levels)...)
+			}
+		}
+	}
+
+	result := *mki3dData
+	result.Model = ModelType{Segments: mki3dData.Model.Segments, Triangles: triangles}
+	result.Texture = nil
+	return &result, nil
+}
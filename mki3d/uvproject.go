@@ -0,0 +1,143 @@
+package mki3d
+
+/* automatic UV coordinates of triangles */
+
+import (
+	"errors"
+	"github.com/go-gl/mathgl/mgl32"
+	"math"
+)
+
+// UVProjectionType is a kind of projection of triangles to UV coordinates
+type UVProjectionType int
+
+const (
+	UVPlanar      UVProjectionType = iota // projection on the plane spanned by UAxis and VAxis
+	UVBox                                 // planar projection on the plane of the frame most parallel to each triangle
+	UVCylindrical                         // angle around VAxis (U) and the distance along VAxis (V)
+	UVSpherical                           // longitude around VAxis (U) and latitude from the south pole (V)
+)
+
+// UVOptions are the parameters of UV projection.
+// The coordinates computed by the projection are multiplied by Scale and moved by Offset.
+// For the planar and box projections and for V of the cylindrical projection they are in the units of the model,
+// so Scale is the number of texture repetitions per unit.
+type UVOptions struct {
+	Projection UVProjectionType
+	Center     Vector3dType // origin of the projection
+	UAxis      Vector3dType // U direction of the planar projection and the zero angle direction (default X axis)
+	VAxis      Vector3dType // V direction of the planar projection and the axis of the cylinder and the sphere (default Y axis)
+	Scale      Vector2dType // zero coordinates mean 1
+	Offset     Vector2dType
+}
+
+// frame returns the unit vectors U, V and W = U x V of the projection (V is made perpendicular to U)
+func (options *UVOptions) frame() (u, v, w mgl32.Vec3) {
+	u, v = mgl32.Vec3(options.UAxis), mgl32.Vec3(options.VAxis)
+	if u.Dot(u) == 0 {
+		u = mgl32.Vec3{1, 0, 0}
+	}
+	if v.Dot(v) == 0 {
+		v = mgl32.Vec3{0, 1, 0}
+	}
+	u = u.Normalize()
+	v = v.Sub(u.Mul(u.Dot(v)))
+	if v.Dot(v) == 0 {
+		_, v = tubeFrame(u)
+	}
+	v = v.Normalize()
+	return u, v, u.Cross(v)
+}
+
+// ProjectUV returns the textured triangles with the endpoints of the triangles and UV coordinates computed
+// by the projection defined in options.
+func (triangles TrianglesType) ProjectUV(options UVOptions) TexturedTrianglesType {
+	u, v, w := options.frame()
+	center := mgl32.Vec3(options.Center)
+	scale := options.Scale
+	for k := 0; k < 2; k++ {
+		if scale[k] == 0 {
+			scale[k] = 1
+		}
+	}
+	angle := func(d mgl32.Vec3) float32 {
+		return float32(math.Atan2(float64(d.Dot(w)), float64(d.Dot(u)))/(2*math.Pi) + 0.5)
+	}
+
+	texTriangles := make(TexturedTrianglesType, 0, len(triangles))
+	for t := range triangles {
+		triangle := &triangles[t]
+		normal := triangle.Normal()
+		var uv TriangleUVType
+		for j := 0; j < 3; j++ {
+			d := mgl32.Vec3(triangle[j].Position).Sub(center)
+			switch options.Projection {
+			case UVBox:
+				nu, nv, nw := normal.Dot(u), normal.Dot(v), normal.Dot(w)
+				switch {
+				case math.Abs(float64(nu)) >= math.Abs(float64(nv)) && math.Abs(float64(nu)) >= math.Abs(float64(nw)):
+					uv[j] = Vector2dType{-sign32(nu) * d.Dot(w), d.Dot(v)}
+				case math.Abs(float64(nv)) >= math.Abs(float64(nw)):
+					uv[j] = Vector2dType{d.Dot(u), -sign32(nv) * d.Dot(w)}
+				default:
+					uv[j] = Vector2dType{sign32(nw) * d.Dot(u), d.Dot(v)}
+				}
+			case UVCylindrical:
+				uv[j] = Vector2dType{angle(d), d.Dot(v)}
+			case UVSpherical:
+				latitude := float32(0.5)
+				if length := d.Len(); length > 0 {
+					latitude = float32(math.Acos(float64(-d.Dot(v)/length)) / math.Pi)
+				}
+				uv[j] = Vector2dType{angle(d), latitude}
+			default: // UVPlanar
+				uv[j] = Vector2dType{d.Dot(u), d.Dot(v)}
+			}
+		}
+		if options.Projection == UVCylindrical || options.Projection == UVSpherical {
+			fixUSeam(&uv)
+		}
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 2; k++ {
+				uv[j][k] = uv[j][k]*scale[k] + options.Offset[k]
+			}
+		}
+		texTriangles = append(texTriangles, TexturedTriangleType{Triangle: *triangle, TriangleUV: uv})
+	}
+	return texTriangles
+}
+
+// sign32 returns -1 for negative x and 1 otherwise
+func sign32(x float32) float32 {
+	if x < 0 {
+		return -1
+	}
+	return 1
+}
+
+// ProjectSetUV moves the triangles of mki3dData.Model with all endpoints in the set to the texture element
+// with the index elementIndex of mki3dData.Texture, with UV coordinates computed by the projection defined in options.
+// It returns the number of moved triangles or an error.
+func (mki3dData *Mki3dType) ProjectSetUV(set int, elementIndex int, options UVOptions) (moved int, err error) {
+	if mki3dData.Texture == nil {
+		return 0, errors.New("mki3dData.Texture == nil // type *TextureType")
+	}
+	if elementIndex < 0 || elementIndex >= len(mki3dData.Texture.Elements) {
+		return 0, errors.New("elementIndex out of range")
+	}
+
+	selected := make(TrianglesType, 0)
+	remaining := make(TrianglesType, 0, len(mki3dData.Model.Triangles))
+	for _, triangle := range mki3dData.Model.Triangles {
+		if triangle[0].Set == set && triangle[1].Set == set && triangle[2].Set == set {
+			selected = append(selected, triangle)
+		} else {
+			remaining = append(remaining, triangle)
+		}
+	}
+
+	texEl := &mki3dData.Texture.Elements[elementIndex]
+	texEl.TexturedTriangles = append(texEl.TexturedTriangles, selected.ProjectUV(options)...)
+	mki3dData.Model.Triangles = remaining
+	return len(selected), nil
+}